@@ -0,0 +1,207 @@
+package log
+
+import (
+	"errors"
+	"sync"
+)
+
+const (
+	OVERFLOW_BLOCK            = iota // 队列满时阻塞写入方
+	OVERFLOW_DROP_NEWEST             // 队列满时丢弃新日志
+	OVERFLOW_DROP_OLDEST             // 队列满时丢弃队列中最旧的日志
+	OVERFLOW_DROP_BELOW_LEVEL        // 队列满时丢弃低于 DropLevel 的新日志，其余阻塞
+)
+
+var (
+	DefaultAsyncQueueSize = 4096
+
+	ErrAsyncClosed = errors.New("log: async writer closed")
+)
+
+type AsyncOptions struct {
+	QueueSize int // 队列容量，<=0 时使用 DefaultAsyncQueueSize
+	Overflow  int // 队列满时的处理策略，OVERFLOW_*
+	DropLevel int // Overflow 为 OVERFLOW_DROP_BELOW_LEVEL 时生效
+}
+
+// AsyncLogWriter 将日志放入有界环形队列，由后台协程写入 inner，写入方不再等待磁盘 IO
+type AsyncLogWriter struct {
+	mu       sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	idle     *sync.Cond
+
+	inner ILogWriter
+	opts  AsyncOptions
+
+	queue []*Log
+	head  int
+	count int
+
+	writing bool
+	closed  bool
+	done    chan struct{}
+
+	dropped        uint64
+	droppedByLevel map[int]uint64
+}
+
+func NewAsyncLogWriter(inner ILogWriter, opts AsyncOptions) *AsyncLogWriter {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = DefaultAsyncQueueSize
+	}
+	w := &AsyncLogWriter{
+		inner:          inner,
+		opts:           opts,
+		queue:          make([]*Log, opts.QueueSize),
+		done:           make(chan struct{}),
+		droppedByLevel: map[int]uint64{},
+	}
+	w.notEmpty = sync.NewCond(&w.mu)
+	w.notFull = sync.NewCond(&w.mu)
+	w.idle = sync.NewCond(&w.mu)
+	go w.run()
+	return w
+}
+
+func (w *AsyncLogWriter) WriteLog(log *Log) (n int, err error) {
+	// 调用位置由 PC 在后台协程中解析
+	entry := *log
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for !w.closed && w.count == len(w.queue) {
+		switch w.opts.Overflow {
+		case OVERFLOW_DROP_NEWEST:
+			w.drop(&entry)
+			return 0, nil
+		case OVERFLOW_DROP_OLDEST:
+			w.drop(w.queue[w.head])
+			w.queue[w.head] = nil
+			w.head = (w.head + 1) % len(w.queue)
+			w.count--
+		case OVERFLOW_DROP_BELOW_LEVEL:
+			if entry.Level < w.opts.DropLevel {
+				w.drop(&entry)
+				return 0, nil
+			}
+			w.notFull.Wait()
+		default:
+			w.notFull.Wait()
+		}
+	}
+	if w.closed {
+		return 0, ErrAsyncClosed
+	}
+
+	w.queue[(w.head+w.count)%len(w.queue)] = &entry
+	w.count++
+	w.notEmpty.Signal()
+	return len(entry.Value), nil
+}
+
+func (w *AsyncLogWriter) drop(log *Log) {
+	w.dropped++
+	w.droppedByLevel[log.Level]++
+}
+
+// Dropped 返回因队列满被丢弃的日志总数
+func (w *AsyncLogWriter) Dropped() uint64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.dropped
+}
+
+// DroppedByLevel 返回按级别统计的丢弃数量
+func (w *AsyncLogWriter) DroppedByLevel() map[int]uint64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	m := make(map[int]uint64, len(w.droppedByLevel))
+	for k, v := range w.droppedByLevel {
+		m[k] = v
+	}
+	return m
+}
+
+// Len 返回队列中等待写入的日志数量
+func (w *AsyncLogWriter) Len() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.count
+}
+
+// Flush 阻塞直到队列中已有的日志全部写入 inner
+func (w *AsyncLogWriter) Flush() {
+	w.mu.Lock()
+	for w.count > 0 || w.writing {
+		w.idle.Wait()
+	}
+	w.mu.Unlock()
+}
+
+// Sync 等待队列写完后对 inner 执行 Sync
+func (w *AsyncLogWriter) Sync() error {
+	w.Flush()
+	return syncWriter(w.inner)
+}
+
+// Close 停止接收新日志，写完队列中剩余日志后退出后台协程
+func (w *AsyncLogWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		<-w.done
+		return nil
+	}
+	w.closed = true
+	w.notEmpty.Broadcast()
+	w.notFull.Broadcast()
+	w.mu.Unlock()
+	<-w.done
+	return nil
+}
+
+func (w *AsyncLogWriter) run() {
+	defer close(w.done)
+
+	batch := make([]*Log, 0, 64)
+	for {
+		w.mu.Lock()
+		for w.count == 0 && !w.closed {
+			w.idle.Broadcast()
+			w.notEmpty.Wait()
+		}
+		if w.count == 0 && w.closed {
+			w.idle.Broadcast()
+			w.mu.Unlock()
+			return
+		}
+		for w.count > 0 && len(batch) < cap(batch) {
+			batch = append(batch, w.queue[w.head])
+			w.queue[w.head] = nil
+			w.head = (w.head + 1) % len(w.queue)
+			w.count--
+		}
+		w.writing = true
+		w.notFull.Broadcast()
+		w.mu.Unlock()
+
+		for i, log := range batch {
+			w.write(log)
+			batch[i] = nil
+		}
+		batch = batch[:0]
+
+		w.mu.Lock()
+		w.writing = false
+		w.mu.Unlock()
+	}
+}
+
+func (w *AsyncLogWriter) write(log *Log) {
+	defer func() {
+		recover()
+	}()
+	w.inner.WriteLog(log)
+}
@@ -0,0 +1,79 @@
+package log
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+type blockingLogWriter struct {
+	sync.Mutex
+	gate   chan struct{}
+	values []string
+}
+
+func (w *blockingLogWriter) WriteLog(log *Log) (n int, err error) {
+	<-w.gate
+	w.Lock()
+	w.values = append(w.values, log.Value)
+	w.Unlock()
+	return len(log.Value), nil
+}
+
+func TestAsyncLogWriterDrop(t *testing.T) {
+	for _, policy := range []int{OVERFLOW_DROP_NEWEST, OVERFLOW_DROP_OLDEST, OVERFLOW_DROP_BELOW_LEVEL} {
+		inner := &blockingLogWriter{gate: make(chan struct{})}
+		w := NewAsyncLogWriter(inner, AsyncOptions{QueueSize: 2, Overflow: policy, DropLevel: LEVEL_ERROR})
+
+		// 第一条被后台协程取走并阻塞在 inner 上
+		w.WriteLog(&Log{Level: LEVEL_INFO, Value: "0", File: "x"})
+		for w.Len() != 0 {
+			time.Sleep(time.Millisecond)
+		}
+		for _, v := range []string{"1", "2", "3"} {
+			w.WriteLog(&Log{Level: LEVEL_INFO, Value: v, File: "x"})
+		}
+		if w.Dropped() != 1 || w.DroppedByLevel()[LEVEL_INFO] != 1 {
+			t.Fatalf("policy %d: dropped = %d", policy, w.Dropped())
+		}
+
+		close(inner.gate)
+		w.Close()
+
+		want := "012"
+		if policy == OVERFLOW_DROP_OLDEST {
+			want = "023"
+		}
+		got := ""
+		for _, v := range inner.values {
+			got += v
+		}
+		if got != want {
+			t.Fatalf("policy %d: got %q, want %q", policy, got, want)
+		}
+		if _, err := w.WriteLog(&Log{Value: "4"}); err != ErrAsyncClosed {
+			t.Fatalf("write after close: %v", err)
+		}
+	}
+}
+
+func TestAsyncLogWriterFlush(t *testing.T) {
+	inner := &testLogWriter{}
+	w := NewAsyncLogWriter(inner, AsyncOptions{QueueSize: 8})
+	defer w.Close()
+
+	logger := NewLogger()
+	logger.SetOutput(nil)
+	logger.SetStructOutput(w)
+	for i := 0; i < 100; i++ {
+		logger.Info("log %d", i)
+	}
+	w.Flush()
+
+	if len(inner.logs) != 100 || w.Dropped() != 0 {
+		t.Fatalf("got %d logs, dropped %d", len(inner.logs), w.Dropped())
+	}
+	if file, _ := inner.logs[0].Caller(false); file != "async_test.go" {
+		t.Fatalf("caller should be kept across goroutines, got %q", file)
+	}
+}
@@ -0,0 +1,73 @@
+package log
+
+import (
+	"runtime"
+	"sync"
+)
+
+type callerFrame struct {
+	line  int
+	short string // 只保留文件名
+	full  string // 去掉 GOPATH、工作目录等前缀
+	pkg   string // 调用者的包路径
+}
+
+// callerFrames 缓存 pc 对应的调用位置，日志调用点有限，不做淘汰
+var callerFrames sync.Map // uintptr -> *callerFrame
+
+func lookupCaller(pc uintptr) *callerFrame {
+	if v, ok := callerFrames.Load(pc); ok {
+		return v.(*callerFrame)
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	if frame.File == "" {
+		return &callerFrame{line: -1, short: "???", full: "???"}
+	}
+	f := &callerFrame{
+		line:  frame.Line,
+		short: trimCallerFile(frame.File, false),
+		full:  trimCallerFile(frame.File, true),
+		pkg:   funcPackage(frame.Function),
+	}
+	callerFrames.Store(pc, f)
+	return f
+}
+
+// callerPC 返回调用栈第 skip 层(相对于 callerPC 的调用者)的 pc
+func callerPC(skip int) uintptr {
+	var pcs [1]uintptr
+	if runtime.Callers(skip+2, pcs[:]) == 0 {
+		return 0
+	}
+	return pcs[0]
+}
+
+// newCallerFrame 由已知的文件路径构造调用位置，用于没有 pc 的日志(如标准库 log)
+func newCallerFrame(file string, line int) *callerFrame {
+	return &callerFrame{
+		line:  line,
+		short: trimCallerFile(file, false),
+		full:  trimCallerFile(file, true),
+	}
+}
+
+// Caller 返回日志的调用位置，首次调用时按 PC 解析(结果按 pc 缓存)，之后每次按 fullPath 选择路径并保存到 File/Line，
+// 自定义的 Formater 和 ILogWriter 应通过 Caller 获取调用位置
+func (log *Log) Caller(fullPath bool) (string, int) {
+	if log.frame == nil && log.File == "" {
+		if log.PC == 0 {
+			log.File, log.Line = "???", -1
+			return log.File, log.Line
+		}
+		log.frame = lookupCaller(log.PC)
+	}
+	if f := log.frame; f != nil {
+		if fullPath {
+			log.File = f.full
+		} else {
+			log.File = f.short
+		}
+		log.Line = f.line
+	}
+	return log.File, log.Line
+}
@@ -0,0 +1,83 @@
+package log
+
+import (
+	"bytes"
+	"io/ioutil"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestLogCaller(t *testing.T) {
+	log := &Log{PC: callerPC(0)}
+	_, _, line, _ := runtime.Caller(0)
+	if file, l := log.Caller(false); file != "caller_test.go" || l != line-1 {
+		t.Fatalf("unexpected caller %s:%d", file, l)
+	}
+	// 已解析时不再按 PC 解析，每次按 fullPath 选择路径
+	log.PC = 0
+	if file, _ := log.Caller(true); file == "caller_test.go" || !strings.HasSuffix(file, "/caller_test.go") {
+		t.Fatalf("full path expected, got %q", file)
+	}
+	if file, _ := log.Caller(false); file != "caller_test.go" {
+		t.Fatalf("short path expected, got %q", file)
+	}
+	if file, l := (&Log{}).Caller(false); file != "???" || l != -1 {
+		t.Fatalf("unexpected caller %s:%d", file, l)
+	}
+}
+
+func TestCallerMixedFullPath(t *testing.T) {
+	text, jsonBuf, logfmtBuf := &bytes.Buffer{}, &bytes.Buffer{}, &bytes.Buffer{}
+	logger := NewLogger()
+	logger.FullPath = false
+	logger.SetOutput(text)
+	jw, lw := NewIOLogWriter(jsonBuf), NewIOLogWriter(logfmtBuf)
+	jw.AppendFormater = (&JSONFormater{CallerKey: "caller", FullPath: true}).Append
+	lw.AppendFormater = (&LogfmtFormater{CallerKey: "caller"}).Append
+	logger.SetStructOutput(MultiLogWriter(jw, lw))
+
+	logger.Info("hi")
+	if !strings.Contains(text.String(), "[caller_test.go:") {
+		t.Fatalf("text should use short path: %q", text.String())
+	}
+	if !strings.Contains(jsonBuf.String(), `/caller_test.go:`) {
+		t.Fatalf("json should use full path: %q", jsonBuf.String())
+	}
+	if !strings.Contains(logfmtBuf.String(), "caller=caller_test.go:") {
+		t.Fatalf("logfmt should use short path: %q", logfmtBuf.String())
+	}
+}
+
+// BenchmarkCallerRuntime 为原来的做法: 默认格式化和 FileWriter 各调用一次 runtime.Caller
+func BenchmarkCallerRuntime(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < 2; j++ {
+			_, file, line, _ := runtime.Caller(0)
+			_, _ = trimCallerFile(file, false), line
+		}
+	}
+}
+
+func BenchmarkCallerPC(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		log := Log{PC: callerPC(0)}
+		log.Caller(false)
+	}
+}
+
+func BenchmarkLoggerFileWriter(b *testing.B) {
+	fw := &FileWriter{RootDir: b.TempDir() + "/", FileFormat: "20060102.log", EnableBufio: true}
+	defer fw.Close()
+	logger := NewLogger()
+	logger.SetOutput(ioutil.Discard)
+	logger.SetStructOutput(fw)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logger.Info("log %d", i)
+	}
+}
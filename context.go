@@ -0,0 +1,286 @@
+package log
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ContextExtractor 从 ctx 中提取需要附加到日志的字段
+type ContextExtractor func(ctx context.Context) []Field
+
+type contextExtractor struct {
+	name string
+	f    ContextExtractor
+}
+
+var (
+	extractorsMu sync.Mutex
+	// extractors 写时复制，按注册顺序执行
+	extractors atomic.Pointer[[]contextExtractor]
+)
+
+func init() {
+	RegisterContextExtractor("request_id", func(ctx context.Context) []Field {
+		if id := RequestID(ctx); id != "" {
+			return []Field{{Key: "request_id", Value: id}}
+		}
+		return nil
+	})
+	RegisterContextExtractor("trace", func(ctx context.Context) []Field {
+		if tc, ok := TraceFromContext(ctx); ok {
+			return []Field{{Key: "trace_id", Value: tc.TraceID}, {Key: "span_id", Value: tc.SpanID}}
+		}
+		return nil
+	})
+	RegisterContextExtractor("tenant_id", func(ctx context.Context) []Field {
+		if id := TenantID(ctx); id != "" {
+			return []Field{{Key: "tenant_id", Value: id}}
+		}
+		return nil
+	})
+}
+
+// RegisterContextExtractor 按名字注册 ContextExtractor，同名的替换原来的，f 为 nil 时删除，
+// 内置 "request_id"、"trace"、"tenant_id" 三个
+func RegisterContextExtractor(name string, f ContextExtractor) {
+	extractorsMu.Lock()
+	defer extractorsMu.Unlock()
+	var old []contextExtractor
+	if p := extractors.Load(); p != nil {
+		old = *p
+	}
+	arr := make([]contextExtractor, 0, len(old)+1)
+	found := false
+	for _, v := range old {
+		if v.name == name {
+			found = true
+			if f == nil {
+				continue
+			}
+			v.f = f
+		}
+		arr = append(arr, v)
+	}
+	if !found && f != nil {
+		arr = append(arr, contextExtractor{name: name, f: f})
+	}
+	extractors.Store(&arr)
+}
+
+// ContextFields 返回所有 ContextExtractor 从 ctx 中提取的字段
+func ContextFields(ctx context.Context) []Field {
+	if ctx == nil {
+		return nil
+	}
+	var fields []Field
+	for _, v := range *extractors.Load() {
+		fields = append(fields, v.f(ctx)...)
+	}
+	return fields
+}
+
+func contextKV(ctx context.Context) []interface{} {
+	if fields := ContextFields(ctx); len(fields) > 0 {
+		return []interface{}{fields}
+	}
+	return nil
+}
+
+type contextKey int
+
+const (
+	requestIDKey contextKey = iota
+	traceKey
+	tenantIDKey
+)
+
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+func WithTenantID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantIDKey, id)
+}
+
+func TenantID(ctx context.Context) string {
+	id, _ := ctx.Value(tenantIDKey).(string)
+	return id
+}
+
+// TraceContext 为 W3C Trace Context 的 traceparent，ID 为小写十六进制
+type TraceContext struct {
+	TraceID string
+	SpanID  string
+	Flags   byte
+}
+
+func (tc TraceContext) Sampled() bool {
+	return tc.Flags&1 != 0
+}
+
+// String 返回 traceparent 头的值
+func (tc TraceContext) String() string {
+	return fmt.Sprintf("00-%s-%s-%02x", tc.TraceID, tc.SpanID, tc.Flags)
+}
+
+// ParseTraceparent 解析 traceparent 头，如 "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+func ParseTraceparent(s string) (TraceContext, error) {
+	var tc TraceContext
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return tc, fmt.Errorf("log ParseTraceparent Error: invalid traceparent %q", s)
+	}
+	// 版本 00 只有 4 段，更高版本允许在后面追加字段
+	if parts[0] == "ff" || parts[0] == "00" && len(parts) != 4 {
+		return tc, fmt.Errorf("log ParseTraceparent Error: invalid traceparent %q", s)
+	}
+	var flags [1]byte
+	for i, p := range parts[:4] {
+		if !isLowerHex(p) {
+			return tc, fmt.Errorf("log ParseTraceparent Error: invalid traceparent %q", s)
+		}
+		if (i == 1 || i == 2) && strings.Trim(p, "0") == "" {
+			return tc, fmt.Errorf("log ParseTraceparent Error: all-zero id in %q", s)
+		}
+	}
+	hex.Decode(flags[:], []byte(parts[3]))
+	tc.TraceID, tc.SpanID, tc.Flags = parts[1], parts[2], flags[0]
+	return tc, nil
+}
+
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func WithTrace(ctx context.Context, tc TraceContext) context.Context {
+	return context.WithValue(ctx, traceKey, tc)
+}
+
+// WithTraceparent 解析 traceparent 头并保存到 ctx，格式错误时返回原 ctx 和错误
+func WithTraceparent(ctx context.Context, traceparent string) (context.Context, error) {
+	tc, err := ParseTraceparent(traceparent)
+	if err != nil {
+		return ctx, err
+	}
+	return WithTrace(ctx, tc), nil
+}
+
+func TraceFromContext(ctx context.Context) (TraceContext, bool) {
+	tc, ok := ctx.Value(traceKey).(TraceContext)
+	return tc, ok
+}
+
+// Ctx 返回附加了 ctx 中字段(见 ContextFields)的子 logger，无字段时返回 logger 本身
+func (logger *Logger) Ctx(ctx context.Context) *Logger {
+	fields := ContextFields(ctx)
+	if len(fields) == 0 {
+		return logger
+	}
+	return logger.With(fields)
+}
+
+func Ctx(ctx context.Context) *Logger {
+	return DefaultLogger.Ctx(ctx)
+}
+
+// XxxCtx 与 Xxx 相同，并附加 ctx 中的字段(见 ContextFields)
+func (logger *Logger) TraceCtx(ctx context.Context, format string, v ...interface{}) {
+	if logger.enabled(0, LEVEL_TRACE) {
+		logger.output(0, LEVEL_TRACE, format, fmt.Sprintf(format, v...), contextKV(ctx), v)
+	}
+}
+
+func (logger *Logger) DebugCtx(ctx context.Context, format string, v ...interface{}) {
+	if logger.enabled(0, LEVEL_DEBUG) {
+		logger.output(0, LEVEL_DEBUG, format, fmt.Sprintf(format, v...), contextKV(ctx), v)
+	}
+}
+
+func (logger *Logger) InfoCtx(ctx context.Context, format string, v ...interface{}) {
+	if logger.enabled(0, LEVEL_INFO) {
+		logger.output(0, LEVEL_INFO, format, fmt.Sprintf(format, v...), contextKV(ctx), v)
+	}
+}
+
+func (logger *Logger) WarnCtx(ctx context.Context, format string, v ...interface{}) {
+	if logger.enabled(0, LEVEL_WARN) {
+		logger.output(0, LEVEL_WARN, format, fmt.Sprintf(format, v...), contextKV(ctx), v)
+	}
+}
+
+func (logger *Logger) ErrorCtx(ctx context.Context, format string, v ...interface{}) {
+	if logger.enabled(0, LEVEL_ERROR) {
+		logger.output(0, LEVEL_ERROR, format, fmt.Sprintf(format, v...), contextKV(ctx), v)
+	}
+}
+
+func (logger *Logger) PanicCtx(ctx context.Context, format string, v ...interface{}) {
+	if logger.enabled(0, LEVEL_PANIC) {
+		logger.panic(logger.output(0, LEVEL_PANIC, format, fmt.Sprintf(format, v...), contextKV(ctx), v))
+	}
+}
+
+func (logger *Logger) FatalCtx(ctx context.Context, format string, v ...interface{}) {
+	if logger.enabled(0, LEVEL_FATAL) {
+		logger.output(0, LEVEL_FATAL, format, fmt.Sprintf(format, v...), contextKV(ctx), v)
+		logger.exit(-1)
+	}
+}
+
+/********* default logger *********/
+func TraceCtx(ctx context.Context, format string, v ...interface{}) {
+	if DefaultLogger.enabled(0, LEVEL_TRACE) {
+		DefaultLogger.output(0, LEVEL_TRACE, format, fmt.Sprintf(format, v...), contextKV(ctx), v)
+	}
+}
+
+func DebugCtx(ctx context.Context, format string, v ...interface{}) {
+	if DefaultLogger.enabled(0, LEVEL_DEBUG) {
+		DefaultLogger.output(0, LEVEL_DEBUG, format, fmt.Sprintf(format, v...), contextKV(ctx), v)
+	}
+}
+
+func InfoCtx(ctx context.Context, format string, v ...interface{}) {
+	if DefaultLogger.enabled(0, LEVEL_INFO) {
+		DefaultLogger.output(0, LEVEL_INFO, format, fmt.Sprintf(format, v...), contextKV(ctx), v)
+	}
+}
+
+func WarnCtx(ctx context.Context, format string, v ...interface{}) {
+	if DefaultLogger.enabled(0, LEVEL_WARN) {
+		DefaultLogger.output(0, LEVEL_WARN, format, fmt.Sprintf(format, v...), contextKV(ctx), v)
+	}
+}
+
+func ErrorCtx(ctx context.Context, format string, v ...interface{}) {
+	if DefaultLogger.enabled(0, LEVEL_ERROR) {
+		DefaultLogger.output(0, LEVEL_ERROR, format, fmt.Sprintf(format, v...), contextKV(ctx), v)
+	}
+}
+
+func PanicCtx(ctx context.Context, format string, v ...interface{}) {
+	if DefaultLogger.enabled(0, LEVEL_PANIC) {
+		DefaultLogger.panic(DefaultLogger.output(0, LEVEL_PANIC, format, fmt.Sprintf(format, v...), contextKV(ctx), v))
+	}
+}
+
+func FatalCtx(ctx context.Context, format string, v ...interface{}) {
+	if DefaultLogger.enabled(0, LEVEL_FATAL) {
+		DefaultLogger.output(0, LEVEL_FATAL, format, fmt.Sprintf(format, v...), contextKV(ctx), v)
+		DefaultLogger.exit(-1)
+	}
+}
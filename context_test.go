@@ -0,0 +1,76 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tc, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if err != nil || tc.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || tc.SpanID != "00f067aa0ba902b7" || !tc.Sampled() {
+		t.Fatalf("unexpected trace context: %+v, %v", tc, err)
+	}
+	if tc.String() != "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01" {
+		t.Fatalf("unexpected traceparent: %s", tc)
+	}
+	for _, s := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-x",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+	} {
+		if _, err := ParseTraceparent(s); err == nil {
+			t.Fatalf("ParseTraceparent(%q) should fail", s)
+		}
+	}
+	if _, err := ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-future"); err != nil {
+		t.Fatalf("future version should be accepted: %v", err)
+	}
+}
+
+func TestContextLogging(t *testing.T) {
+	ctx := WithRequestID(context.Background(), "req-1")
+	ctx = WithTenantID(ctx, "acme")
+	ctx, err := WithTraceparent(ctx, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	logger := NewLogger()
+	logger.SetOutput(buf)
+
+	logger.InfoCtx(ctx, "hello %d", 1)
+	logger.Ctx(ctx).InfoKV("kv", "k", "v")
+	logger.Ctx(context.Background()).Info("plain")
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	want := " request_id=req-1 trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7 tenant_id=acme"
+	if len(lines) != 3 || !strings.HasSuffix(lines[0], "hello 1"+want) || !strings.HasSuffix(lines[1], "kv"+want+" k=v") || !strings.HasSuffix(lines[2], "] plain") {
+		t.Fatalf("unexpected output: %q", buf.String())
+	}
+	if !strings.Contains(lines[0], "[context_test.go:") {
+		t.Fatalf("unexpected caller: %q", lines[0])
+	}
+
+	type userKey struct{}
+	RegisterContextExtractor("user", func(ctx context.Context) []Field {
+		if u, ok := ctx.Value(userKey{}).(string); ok {
+			return []Field{F("user", u)}
+		}
+		return nil
+	})
+	defer RegisterContextExtractor("user", nil)
+
+	buf.Reset()
+	logger.SetAppendFormater(NewJSONFormater().Append)
+	slog.New(NewSlogHandler(logger)).InfoContext(context.WithValue(ctx, userKey{}, "bob"), "slog")
+	m := map[string]interface{}{}
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil || m["request_id"] != "req-1" || m["user"] != "bob" || m["tenant_id"] != "acme" {
+		t.Fatalf("unexpected json: %q, %v", buf.String(), err)
+	}
+}
@@ -0,0 +1,115 @@
+package log
+
+import "fmt"
+
+// WithCallerSkip 返回额外跳过 n 层调用的子 logger，用于封装日志方法的辅助函数，
+// 使日志中的文件名、行号及模块级别匹配使用辅助函数的调用者
+func (logger *Logger) WithCallerSkip(n int) *Logger {
+	child := logger.child()
+	child.depth += n
+	return child
+}
+
+func WithCallerSkip(n int) *Logger {
+	return DefaultLogger.WithCallerSkip(n)
+}
+
+// LogDepth 以任意级别输出日志，depth 为额外跳过的调用层数，0 与 Logf 相同
+func (logger *Logger) LogDepth(depth, level int, format string, v ...interface{}) {
+	if logger.enabled(depth, level) {
+		logger.output(depth, level, format, fmt.Sprintf(format, v...), nil, v)
+	}
+}
+
+func (logger *Logger) TraceDepth(depth int, format string, v ...interface{}) {
+	if logger.enabled(depth, LEVEL_TRACE) {
+		logger.output(depth, LEVEL_TRACE, format, fmt.Sprintf(format, v...), nil, v)
+	}
+}
+
+func (logger *Logger) DebugDepth(depth int, format string, v ...interface{}) {
+	if logger.enabled(depth, LEVEL_DEBUG) {
+		logger.output(depth, LEVEL_DEBUG, format, fmt.Sprintf(format, v...), nil, v)
+	}
+}
+
+func (logger *Logger) InfoDepth(depth int, format string, v ...interface{}) {
+	if logger.enabled(depth, LEVEL_INFO) {
+		logger.output(depth, LEVEL_INFO, format, fmt.Sprintf(format, v...), nil, v)
+	}
+}
+
+func (logger *Logger) WarnDepth(depth int, format string, v ...interface{}) {
+	if logger.enabled(depth, LEVEL_WARN) {
+		logger.output(depth, LEVEL_WARN, format, fmt.Sprintf(format, v...), nil, v)
+	}
+}
+
+func (logger *Logger) ErrorDepth(depth int, format string, v ...interface{}) {
+	if logger.enabled(depth, LEVEL_ERROR) {
+		logger.output(depth, LEVEL_ERROR, format, fmt.Sprintf(format, v...), nil, v)
+	}
+}
+
+func (logger *Logger) PanicDepth(depth int, format string, v ...interface{}) {
+	if logger.enabled(depth, LEVEL_PANIC) {
+		logger.panic(logger.output(depth, LEVEL_PANIC, format, fmt.Sprintf(format, v...), nil, v))
+	}
+}
+
+func (logger *Logger) FatalDepth(depth int, format string, v ...interface{}) {
+	if logger.enabled(depth, LEVEL_FATAL) {
+		logger.output(depth, LEVEL_FATAL, format, fmt.Sprintf(format, v...), nil, v)
+		logger.exit(-1)
+	}
+}
+
+/********* default logger *********/
+func LogDepth(depth, level int, format string, v ...interface{}) {
+	if DefaultLogger.enabled(depth, level) {
+		DefaultLogger.output(depth, level, format, fmt.Sprintf(format, v...), nil, v)
+	}
+}
+
+func TraceDepth(depth int, format string, v ...interface{}) {
+	if DefaultLogger.enabled(depth, LEVEL_TRACE) {
+		DefaultLogger.output(depth, LEVEL_TRACE, format, fmt.Sprintf(format, v...), nil, v)
+	}
+}
+
+func DebugDepth(depth int, format string, v ...interface{}) {
+	if DefaultLogger.enabled(depth, LEVEL_DEBUG) {
+		DefaultLogger.output(depth, LEVEL_DEBUG, format, fmt.Sprintf(format, v...), nil, v)
+	}
+}
+
+func InfoDepth(depth int, format string, v ...interface{}) {
+	if DefaultLogger.enabled(depth, LEVEL_INFO) {
+		DefaultLogger.output(depth, LEVEL_INFO, format, fmt.Sprintf(format, v...), nil, v)
+	}
+}
+
+func WarnDepth(depth int, format string, v ...interface{}) {
+	if DefaultLogger.enabled(depth, LEVEL_WARN) {
+		DefaultLogger.output(depth, LEVEL_WARN, format, fmt.Sprintf(format, v...), nil, v)
+	}
+}
+
+func ErrorDepth(depth int, format string, v ...interface{}) {
+	if DefaultLogger.enabled(depth, LEVEL_ERROR) {
+		DefaultLogger.output(depth, LEVEL_ERROR, format, fmt.Sprintf(format, v...), nil, v)
+	}
+}
+
+func PanicDepth(depth int, format string, v ...interface{}) {
+	if DefaultLogger.enabled(depth, LEVEL_PANIC) {
+		DefaultLogger.panic(DefaultLogger.output(depth, LEVEL_PANIC, format, fmt.Sprintf(format, v...), nil, v))
+	}
+}
+
+func FatalDepth(depth int, format string, v ...interface{}) {
+	if DefaultLogger.enabled(depth, LEVEL_FATAL) {
+		DefaultLogger.output(depth, LEVEL_FATAL, format, fmt.Sprintf(format, v...), nil, v)
+		DefaultLogger.exit(-1)
+	}
+}
@@ -0,0 +1,59 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// prevLine 返回调用者上一行的 [file:line]
+func prevLine() string {
+	_, file, line, _ := runtime.Caller(1)
+	return fmt.Sprintf("[%s:%d]", file[strings.LastIndex(file, "/")+1:], line-1)
+}
+
+func logHelper(logger *Logger, msg string) {
+	logger.WithCallerSkip(1).Info("%s", msg)
+}
+
+func logDepthHelper(logger *Logger, msg string) {
+	logger.InfoDepth(1, "%s", msg)
+}
+
+func TestCallerSkip(t *testing.T) {
+	dir := t.TempDir() + "/"
+	fw := &FileWriter{RootDir: dir, FileFormat: "20060102.log"}
+	defer fw.Close()
+
+	buf := &bytes.Buffer{}
+	logger := NewLogger()
+	logger.SetOutput(buf)
+	logger.SetStructOutput(fw)
+
+	logHelper(logger, "skip")
+	want1 := prevLine()
+	logDepthHelper(logger.Named("n"), "depth")
+	want2 := prevLine()
+	logger.Info("direct")
+	want3 := prevLine()
+
+	data, err := ioutil.ReadFile(dir + time.Now().Format(fw.FileFormat))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, out := range []string{buf.String(), string(data)} {
+		lines := strings.Split(strings.TrimSpace(out), "\n")
+		if len(lines) != 3 {
+			t.Fatalf("unexpected output: %q", out)
+		}
+		for i, want := range []string{want1, want2, want3} {
+			if !strings.Contains(lines[i], want) {
+				t.Fatalf("line %d: want %s in %q", i, want, lines[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package log
+
+import (
+	"fmt"
+	"strconv"
+)
+
+const badKey = "!BADKEY"
+
+type Field struct {
+	Key   string      `json:"Key"`
+	Value interface{} `json:"Value"`
+}
+
+func F(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Fields 将 key/value 交替排列的参数转换为 Field 列表，参数本身为 Field 时直接使用
+func Fields(kv ...interface{}) []Field {
+	if len(kv) == 0 {
+		return nil
+	}
+	fields := make([]Field, 0, (len(kv)+1)/2)
+	for i := 0; i < len(kv); i++ {
+		switch k := kv[i].(type) {
+		case Field:
+			fields = append(fields, k)
+		case []Field:
+			fields = append(fields, k...)
+		case string:
+			if i+1 < len(kv) {
+				fields = append(fields, Field{Key: k, Value: kv[i+1]})
+				i++
+			} else {
+				fields = append(fields, Field{Key: badKey, Value: k})
+			}
+		default:
+			fields = append(fields, Field{Key: badKey, Value: k})
+		}
+	}
+	return fields
+}
+
+func (f Field) String() string {
+	return f.Key + "=" + fieldText(f.Value)
+}
+
+func fieldValue(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return "<nil>"
+	case string:
+		return x
+	case error:
+		return x.Error()
+	case fmt.Stringer:
+		return x.String()
+	}
+	return fmt.Sprint(v)
+}
+
+func fieldText(v interface{}) string {
+	return string(appendFieldText(nil, v))
+}
+
+func needQuote(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, c := range s {
+		if c <= ' ' || c == '=' || c == '"' || c == 0x7f || c == '\uFFFD' {
+			return true
+		}
+	}
+	return false
+}
+
+// formatFields 按 " k1=v1 k2=v2" 的形式输出字段，无字段时返回空串
+func formatFields(fields []Field) string {
+	return string(appendFields(nil, fields))
+}
+
+func appendFields(buf []byte, fields []Field) []byte {
+	for _, f := range fields {
+		buf = append(buf, ' ')
+		buf = append(buf, f.Key...)
+		buf = append(buf, '=')
+		buf = appendFieldText(buf, f.Value)
+	}
+	return buf
+}
+
+// appendFieldText 将 fieldText 的结果追加到 buf，常见类型不产生额外的内存分配
+func appendFieldText(buf []byte, v interface{}) []byte {
+	switch x := v.(type) {
+	case string:
+		return appendQuoted(buf, x)
+	case int:
+		return strconv.AppendInt(buf, int64(x), 10)
+	case int64:
+		return strconv.AppendInt(buf, x, 10)
+	case int32:
+		return strconv.AppendInt(buf, int64(x), 10)
+	case uint:
+		return strconv.AppendUint(buf, uint64(x), 10)
+	case uint64:
+		return strconv.AppendUint(buf, x, 10)
+	case uint32:
+		return strconv.AppendUint(buf, uint64(x), 10)
+	case bool:
+		return strconv.AppendBool(buf, x)
+	case float64:
+		return strconv.AppendFloat(buf, x, 'g', -1, 64)
+	case float32:
+		return strconv.AppendFloat(buf, float64(x), 'g', -1, 32)
+	}
+	return appendQuoted(buf, fieldValue(v))
+}
+
+// appendQuoted 需要时加引号追加 s
+func appendQuoted(buf []byte, s string) []byte {
+	if needQuote(s) {
+		return strconv.AppendQuote(buf, s)
+	}
+	return append(buf, s...)
+}
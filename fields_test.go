@@ -0,0 +1,71 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type testLogWriter struct {
+	logs []*Log
+}
+
+func (w *testLogWriter) WriteLog(log *Log) (n int, err error) {
+	// log 在 WriteLog 返回后会被复用
+	entry := *log
+	w.logs = append(w.logs, &entry)
+	return 0, nil
+}
+
+func TestFields(t *testing.T) {
+	fields := Fields("a", 1, F("b", "x y"), "c")
+	if len(fields) != 3 {
+		t.Fatalf("len(fields) = %d, want 3", len(fields))
+	}
+	if s := formatFields(fields); s != ` a=1 b="x y" !BADKEY=c` {
+		t.Fatalf("formatFields = %q", s)
+	}
+}
+
+func TestLoggerWith(t *testing.T) {
+	buf := &bytes.Buffer{}
+	lw := &testLogWriter{}
+	logger := NewLogger()
+	logger.SetOutput(buf)
+	logger.SetStructOutput(lw)
+
+	child := logger.With("user_id", 42)
+	child.InfoKV("login", "err", errors.New("bad password"))
+	child.Debug("plain %d", 1)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines: %q", len(lines), buf.String())
+	}
+	if !strings.Contains(lines[0], "[fields_test.go:") || !strings.HasSuffix(lines[0], `login user_id=42 err="bad password"`) {
+		t.Fatalf("unexpected line: %q", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], "plain 1 user_id=42") {
+		t.Fatalf("unexpected line: %q", lines[1])
+	}
+	if len(lw.logs) != 2 || len(lw.logs[0].Fields) != 2 || lw.logs[0].Fields[1].Key != "err" {
+		t.Fatalf("unexpected struct logs: %+v", lw.logs)
+	}
+
+	child.SetLevel(LEVEL_WARN)
+	if logger.GetLevel() != LEVEL_WARN {
+		t.Fatalf("child SetLevel should apply to parent")
+	}
+
+	// 子 logger 的 Lock 锁定根 logger
+	child.Named("x").Lock()
+	if logger.TryLock() {
+		t.Fatalf("child Lock should lock the root logger")
+	}
+	child.Unlock()
+	if !logger.TryLock() {
+		t.Fatalf("child Unlock should unlock the root logger")
+	}
+	logger.Unlock()
+}
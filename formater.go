@@ -0,0 +1,76 @@
+package log
+
+import (
+	"strconv"
+	"strings"
+)
+
+// trimCallerFile fullPath 时去掉 GOPATH、工作目录等前缀，否则只保留文件名
+func trimCallerFile(file string, fullPath bool) string {
+	if fullPath {
+		for _, v := range filepaths {
+			tmp := strings.Replace(file, v, "", 1)
+			if tmp != file {
+				return tmp
+			}
+		}
+	} else {
+		pos := strings.LastIndex(file, "/")
+		if pos >= 0 {
+			file = file[pos+1:]
+		}
+	}
+	return file
+}
+
+// levelTag 返回右对齐到 5 个字符的级别文本，如 " Info"，LEVEL_PRINT 及未注册的级别返回空串
+func levelTag(lvl int) string {
+	info, ok := lookupLevel(lvl)
+	if !ok || lvl == LEVEL_PRINT || lvl == LEVEL_NONE {
+		return ""
+	}
+	return info.tag
+}
+
+// formatText 输出默认的文本格式: "time [Level] [name] [file:line] msg k=v"，无 name 时省略 [name]
+func formatText(log *Log, layout string) string {
+	return string(appendText(nil, log, layout))
+}
+
+// appendText 将 formatText 的结果追加到 buf
+func appendText(buf []byte, log *Log, layout string) []byte {
+	tag := levelTag(log.Level)
+	if tag == "" {
+		return buf
+	}
+	buf = log.Now.AppendFormat(buf, layout)
+	buf = append(buf, " ["...)
+	buf = append(buf, tag...)
+	buf = append(buf, "] "...)
+	if log.Name != "" {
+		buf = append(buf, '[')
+		buf = append(buf, log.Name...)
+		buf = append(buf, "] "...)
+	}
+	buf = append(buf, '[')
+	buf = appendCaller(buf, log)
+	buf = append(buf, "] "...)
+	buf = append(buf, log.Value...)
+	buf = appendFields(buf, log.Fields)
+	return appendStackText(buf, log.Stack)
+}
+
+func appendCaller(buf []byte, log *Log) []byte {
+	buf = append(buf, log.File...)
+	buf = append(buf, ':')
+	return strconv.AppendInt(buf, int64(log.Line), 10)
+}
+
+// appendStackText 调用栈另起一行输出
+func appendStackText(buf []byte, stack string) []byte {
+	if stack == "" {
+		return buf
+	}
+	buf = append(buf, '\n')
+	return append(buf, stack...)
+}
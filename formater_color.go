@@ -0,0 +1,91 @@
+package log
+
+import (
+	"io"
+	"os"
+)
+
+const (
+	COLOR_NEVER  = iota // 不输出颜色
+	COLOR_AUTO          // Writer 为终端且 NO_COLOR 环境变量为空时输出颜色
+	COLOR_ALWAYS        // 总是输出颜色
+)
+
+const (
+	colorReset  = "\x1b[0m"
+	colorDim    = "\x1b[2m"
+	colorCaller = "\x1b[1m"
+)
+
+// isTerminal 判断 w 是否为终端
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// colorEnabled 按 mode 及 out 判断是否输出颜色
+func colorEnabled(mode int, out io.Writer) bool {
+	switch mode {
+	case COLOR_ALWAYS:
+		return true
+	case COLOR_AUTO:
+		// NO_COLOR 非空时禁用颜色，见 https://no-color.org
+		return os.Getenv("NO_COLOR") == "" && isTerminal(out)
+	}
+	return false
+}
+
+// formatColorText 与 formatText 的列布局相同，时间变暗、级别按 LevelColor 着色、调用位置加粗
+func formatColorText(log *Log, layout string) string {
+	return string(appendColorText(nil, log, layout))
+}
+
+func appendColorText(buf []byte, log *Log, layout string) []byte {
+	tag := levelTag(log.Level)
+	if tag == "" {
+		return buf
+	}
+	buf = append(buf, colorDim...)
+	buf = log.Now.AppendFormat(buf, layout)
+	buf = append(buf, colorReset...)
+	buf = append(buf, " ["...)
+	if color := LevelColor(log.Level); color != "" {
+		buf = append(buf, color...)
+		buf = append(buf, tag...)
+		buf = append(buf, colorReset...)
+	} else {
+		buf = append(buf, tag...)
+	}
+	buf = append(buf, "] "...)
+	if log.Name != "" {
+		buf = append(buf, '[')
+		buf = append(buf, log.Name...)
+		buf = append(buf, "] "...)
+	}
+	buf = append(buf, '[')
+	buf = append(buf, colorCaller...)
+	buf = appendCaller(buf, log)
+	buf = append(buf, colorReset...)
+	buf = append(buf, "] "...)
+	buf = append(buf, log.Value...)
+	buf = appendFields(buf, log.Fields)
+	return appendStackText(buf, log.Stack)
+}
+
+// SetColor 设置默认格式化的彩色输出模式 COLOR_NEVER/COLOR_AUTO/COLOR_ALWAYS，
+// COLOR_AUTO 在 SetColor 和 SetOutput 时按当前 Writer 检测终端
+func (logger *Logger) SetColor(mode int) {
+	root := logger.root()
+	root.Lock()
+	root.Color = mode
+	root.colored = colorEnabled(mode, root.Writer)
+	root.Unlock()
+}
+
+func SetColor(mode int) {
+	DefaultLogger.SetColor(mode)
+}
@@ -0,0 +1,40 @@
+package log
+
+import (
+	"bytes"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestColorFormater(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := NewLogger()
+	logger.SetOutput(buf)
+
+	logger.SetColor(COLOR_AUTO)
+	logger.Warn("plain")
+	logger.SetColor(COLOR_ALWAYS)
+	logger.Warn("colored")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || strings.Contains(lines[0], "\x1b[") {
+		t.Fatalf("buffer is not a terminal, got %q", buf.String())
+	}
+	if !strings.Contains(lines[1], "\x1b[33m Warn\x1b[0m") {
+		t.Fatalf("level should be colored: %q", lines[1])
+	}
+	// 去掉颜色后列布局与默认格式一致
+	stripped := regexp.MustCompile("\x1b\\[[0-9;]*m").ReplaceAllString(lines[1], "")
+	layout := regexp.MustCompile(`^[0-9-]+ [0-9:.]+ \[ Warn\] \[formater_color_test.go:[0-9]+\] `)
+	if !layout.MatchString(lines[0]) || !layout.MatchString(stripped) {
+		t.Fatalf("layout differs:\n%q\n%q", lines[0], stripped)
+	}
+
+	os.Setenv("NO_COLOR", "1")
+	defer os.Unsetenv("NO_COLOR")
+	if colorEnabled(COLOR_AUTO, os.Stdout) {
+		t.Fatalf("NO_COLOR should disable color")
+	}
+}
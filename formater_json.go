@@ -0,0 +1,229 @@
+package log
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"unicode/utf8"
+)
+
+const (
+	JSONTimeUnix      = "unix"
+	JSONTimeUnixMilli = "unixms"
+	JSONTimeUnixNano  = "unixnano"
+)
+
+// JSONFormater 每条日志输出为一行 JSON 对象，可通过 Logger.SetFormater(f.Format)
+// 或 FileWriter.SetFormater(f.Format) 安装
+type JSONFormater struct {
+	TimeKey    string
+	LevelKey   string
+	NameKey    string
+	CallerKey  string
+	MessageKey string
+	StackKey   string
+
+	// TimeFormat 为 time.Format 的 layout，或 JSONTimeUnix/JSONTimeUnixMilli/JSONTimeUnixNano
+	TimeFormat string
+	FullPath   bool
+}
+
+func NewJSONFormater() *JSONFormater {
+	return &JSONFormater{
+		TimeKey:    "time",
+		LevelKey:   "level",
+		NameKey:    "logger",
+		CallerKey:  "caller",
+		MessageKey: "msg",
+		StackKey:   "stack",
+		TimeFormat: DefaultLogTimeLayout,
+	}
+}
+
+func (f *JSONFormater) Format(log *Log) string {
+	return string(f.Append(nil, log))
+}
+
+// Append 将格式化结果追加到 buf，可通过 Logger.SetAppendFormater(f.Append) 安装
+func (f *JSONFormater) Append(buf []byte, log *Log) []byte {
+	log.Caller(f.FullPath)
+
+	start := len(buf)
+	buf = append(buf, '{')
+	if f.TimeKey != "" {
+		buf = appendJSONKey(buf, f.TimeKey, false)
+		switch f.TimeFormat {
+		case JSONTimeUnix:
+			buf = strconv.AppendInt(buf, log.Now.Unix(), 10)
+		case JSONTimeUnixMilli:
+			buf = strconv.AppendInt(buf, log.Now.UnixNano()/1e6, 10)
+		case JSONTimeUnixNano:
+			buf = strconv.AppendInt(buf, log.Now.UnixNano(), 10)
+		default:
+			buf = append(buf, '"')
+			buf = log.Now.AppendFormat(buf, f.TimeFormat)
+			buf = append(buf, '"')
+		}
+	}
+	if f.LevelKey != "" {
+		buf = appendJSONKey(buf, f.LevelKey, len(buf) > start+1)
+		buf = appendJSONString(buf, levelLowerText(log.Level))
+	}
+	if f.NameKey != "" && log.Name != "" {
+		buf = appendJSONKey(buf, f.NameKey, len(buf) > start+1)
+		buf = appendJSONString(buf, log.Name)
+	}
+	if f.CallerKey != "" {
+		buf = appendJSONKey(buf, f.CallerKey, len(buf) > start+1)
+		buf = append(buf, '"')
+		buf = appendJSONEscaped(buf, log.File)
+		buf = append(buf, ':')
+		buf = strconv.AppendInt(buf, int64(log.Line), 10)
+		buf = append(buf, '"')
+	}
+	if f.MessageKey != "" {
+		buf = appendJSONKey(buf, f.MessageKey, len(buf) > start+1)
+		buf = appendJSONString(buf, log.Value)
+	}
+	for _, field := range log.Fields {
+		sep := len(buf) > start+1
+		if key := field.Key; key == f.TimeKey || key == f.LevelKey || key == f.NameKey || key == f.CallerKey || key == f.MessageKey || key == f.StackKey {
+			if sep {
+				buf = append(buf, ',')
+			}
+			buf = append(buf, `"fields.`...)
+			buf = appendJSONEscaped(buf, key)
+			buf = append(buf, `":`...)
+		} else {
+			buf = appendJSONKey(buf, key, sep)
+		}
+		buf = appendJSONValue(buf, field.Value)
+	}
+	if f.StackKey != "" && log.Stack != "" {
+		buf = appendJSONKey(buf, f.StackKey, len(buf) > start+1)
+		buf = appendJSONString(buf, log.Stack)
+	}
+	return append(buf, '}')
+}
+
+func appendJSONKey(buf []byte, key string, sep bool) []byte {
+	if sep {
+		buf = append(buf, ',')
+	}
+	buf = appendJSONString(buf, key)
+	return append(buf, ':')
+}
+
+func appendJSONString(buf []byte, s string) []byte {
+	buf = append(buf, '"')
+	buf = appendJSONEscaped(buf, s)
+	return append(buf, '"')
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendJSONEscaped 与 encoding/json 相同的转义规则(包括 HTML 字符)，不含两端的引号
+func appendJSONEscaped(buf []byte, s string) []byte {
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= ' ' && b != '"' && b != '\\' && b != '<' && b != '>' && b != '&' {
+				i++
+				continue
+			}
+			buf = append(buf, s[start:i]...)
+			switch b {
+			case '"', '\\':
+				buf = append(buf, '\\', b)
+			case '\n':
+				buf = append(buf, '\\', 'n')
+			case '\r':
+				buf = append(buf, '\\', 'r')
+			case '\t':
+				buf = append(buf, '\\', 't')
+			default:
+				buf = append(buf, '\\', 'u', '0', '0', hexDigits[b>>4], hexDigits[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		c, size := utf8.DecodeRuneInString(s[i:])
+		if c == utf8.RuneError && size == 1 {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, `\ufffd`...)
+			i += size
+			start = i
+			continue
+		}
+		if c == '\u2028' || c == '\u2029' {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, '\\', 'u', '2', '0', '2', hexDigits[c&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	return append(buf, s[start:]...)
+}
+
+func appendJSONValue(buf []byte, v interface{}) []byte {
+	switch x := v.(type) {
+	case nil:
+		return append(buf, "null"...)
+	case string:
+		return appendJSONString(buf, x)
+	case bool:
+		return strconv.AppendBool(buf, x)
+	case int:
+		return strconv.AppendInt(buf, int64(x), 10)
+	case int64:
+		return strconv.AppendInt(buf, x, 10)
+	case int32:
+		return strconv.AppendInt(buf, int64(x), 10)
+	case uint:
+		return strconv.AppendUint(buf, uint64(x), 10)
+	case uint64:
+		return strconv.AppendUint(buf, x, 10)
+	case uint32:
+		return strconv.AppendUint(buf, uint64(x), 10)
+	case float64:
+		return appendJSONFloat(buf, x, 64)
+	case float32:
+		return appendJSONFloat(buf, float64(x), 32)
+	}
+	if e, ok := v.(error); ok {
+		if _, ok := v.(json.Marshaler); !ok {
+			return appendJSONString(buf, e.Error())
+		}
+	}
+	if data, err := json.Marshal(v); err == nil {
+		return append(buf, data...)
+	}
+	return appendJSONString(buf, fieldValue(v))
+}
+
+// appendJSONFloat 与 encoding/json 的浮点数格式相同，NaN 和 Inf 输出为字符串
+func appendJSONFloat(buf []byte, f float64, bits int) []byte {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		buf = append(buf, '"')
+		buf = strconv.AppendFloat(buf, f, 'g', -1, bits)
+		return append(buf, '"')
+	}
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) || bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+	buf = strconv.AppendFloat(buf, f, format, -1, bits)
+	if format == 'e' {
+		// 与 encoding/json 一致，e-09 输出为 e-9
+		if n := len(buf); n >= 4 && buf[n-4] == 'e' && buf[n-3] == '-' && buf[n-2] == '0' {
+			buf[n-2] = buf[n-1]
+			buf = buf[:n-1]
+		}
+	}
+	return buf
+}
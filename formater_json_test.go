@@ -0,0 +1,66 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONFormater(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := NewLogger()
+	logger.SetOutput(buf)
+	logger.SetFormater(NewJSONFormater().Format)
+
+	logger.With("msg", "dup").InfoKV("hello \"json\"", "n", 3, "d", time.Second)
+
+	m := map[string]interface{}{}
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatalf("invalid json %q: %v", buf.String(), err)
+	}
+	if m["level"] != "info" || m["msg"] != `hello "json"` || m["n"] != float64(3) || m["fields.msg"] != "dup" {
+		t.Fatalf("unexpected json: %v", m)
+	}
+	if caller, _ := m["caller"].(string); !strings.HasPrefix(caller, "formater_json_test.go:") {
+		t.Fatalf("unexpected caller: %v", m["caller"])
+	}
+}
+
+func TestJSONFormaterFileWriter(t *testing.T) {
+	dir := t.TempDir() + "/"
+	f := NewJSONFormater()
+	f.TimeFormat = JSONTimeUnixMilli
+	fileWriter := &FileWriter{
+		RootDir:    dir,
+		FileFormat: "20060102.log",
+		SaveEach:   true,
+	}
+	fileWriter.SetFormater(f.Format)
+
+	logger := NewLogger()
+	logger.SetOutput(nil)
+	logger.SetStructOutput(fileWriter)
+	logger.Warn("a")
+	logger.Error("b")
+
+	data, err := ioutil.ReadFile(dir + time.Now().Format(fileWriter.FileFormat))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines: %q", len(lines), data)
+	}
+	for _, line := range lines {
+		m := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("invalid json %q: %v", line, err)
+		}
+		if _, ok := m["time"].(float64); !ok {
+			t.Fatalf("time should be numeric: %v", m)
+		}
+	}
+}
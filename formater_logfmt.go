@@ -0,0 +1,102 @@
+package log
+
+import (
+	"strconv"
+	"unicode/utf8"
+)
+
+// LogfmtFormater 每条日志输出为一行 logfmt: time=... level=... logger=... caller=... msg=... k=v，
+// 可通过 Logger.SetFormater(f.Format) 或 FileWriter.SetFormater(f.Format) 安装
+type LogfmtFormater struct {
+	TimeKey    string
+	LevelKey   string
+	NameKey    string
+	CallerKey  string
+	MessageKey string
+	StackKey   string
+
+	TimeFormat string
+	FullPath   bool
+}
+
+func NewLogfmtFormater() *LogfmtFormater {
+	return &LogfmtFormater{
+		TimeKey:    "time",
+		LevelKey:   "level",
+		NameKey:    "logger",
+		CallerKey:  "caller",
+		MessageKey: "msg",
+		StackKey:   "stack",
+		TimeFormat: "2006-01-02T15:04:05.000Z07:00",
+	}
+}
+
+func (f *LogfmtFormater) Format(log *Log) string {
+	return string(f.Append(nil, log))
+}
+
+// Append 将格式化结果追加到 buf，可通过 Logger.SetAppendFormater(f.Append) 安装
+func (f *LogfmtFormater) Append(buf []byte, log *Log) []byte {
+	log.Caller(f.FullPath)
+
+	start := len(buf)
+	if f.TimeKey != "" {
+		buf = appendLogfmtKey(buf, f.TimeKey, false)
+		n := len(buf)
+		buf = log.Now.AppendFormat(buf, f.TimeFormat)
+		buf = quoteLogfmtTail(buf, n)
+	}
+	if f.LevelKey != "" {
+		buf = appendLogfmtKey(buf, f.LevelKey, len(buf) > start)
+		buf = appendQuoted(buf, levelLowerText(log.Level))
+	}
+	if f.NameKey != "" && log.Name != "" {
+		buf = appendLogfmtKey(buf, f.NameKey, len(buf) > start)
+		buf = appendQuoted(buf, log.Name)
+	}
+	if f.CallerKey != "" {
+		buf = appendLogfmtKey(buf, f.CallerKey, len(buf) > start)
+		n := len(buf)
+		buf = appendCaller(buf, log)
+		buf = quoteLogfmtTail(buf, n)
+	}
+	if f.MessageKey != "" {
+		buf = appendLogfmtKey(buf, f.MessageKey, len(buf) > start)
+		buf = appendQuoted(buf, log.Value)
+	}
+	for _, field := range log.Fields {
+		buf = appendLogfmtKey(buf, field.Key, len(buf) > start)
+		buf = appendFieldText(buf, field.Value)
+	}
+	if f.StackKey != "" && log.Stack != "" {
+		buf = appendLogfmtKey(buf, f.StackKey, len(buf) > start)
+		buf = appendQuoted(buf, log.Stack)
+	}
+	return buf
+}
+
+// appendLogfmtKey 追加 "key="，key 中的空白、'='、'"' 等替换为 '_'，空 key 输出为 "_"
+func appendLogfmtKey(buf []byte, key string, sep bool) []byte {
+	if sep {
+		buf = append(buf, ' ')
+	}
+	if key == "" {
+		return append(buf, "_="...)
+	}
+	for _, c := range key {
+		if c <= ' ' || c == '=' || c == '"' || c == 0x7f {
+			c = '_'
+		}
+		buf = utf8.AppendRune(buf, c)
+	}
+	return append(buf, '=')
+}
+
+// quoteLogfmtTail 需要时为 buf[n:] 加引号
+func quoteLogfmtTail(buf []byte, n int) []byte {
+	if !needQuote(string(buf[n:])) {
+		return buf
+	}
+	value := string(buf[n:])
+	return strconv.AppendQuote(buf[:n], value)
+}
@@ -0,0 +1,30 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLogfmtFormater(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := NewLogger()
+	logger.SetOutput(buf)
+	f := NewLogfmtFormater()
+	f.TimeKey = ""
+	logger.SetFormater(f.Format)
+
+	logger.WarnKV("disk full", "path", `C:\data "x"`, "line\nbreak", "a\nb", "empty", "", "n", 7)
+
+	line := strings.TrimSuffix(buf.String(), "\n")
+	if !strings.HasPrefix(line, "level=warn caller=formater_logfmt_test.go:") {
+		t.Fatalf("unexpected prefix: %q", line)
+	}
+	want := ` msg="disk full" path="C:\\data \"x\"" line_break="a\nb" empty="" n=7`
+	if !strings.HasSuffix(line, want) {
+		t.Fatalf("got %q, want suffix %q", line, want)
+	}
+	if strings.Contains(line, "\n") {
+		t.Fatalf("newline must be escaped: %q", line)
+	}
+}
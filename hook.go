@@ -0,0 +1,118 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrDropLog 由 hook 返回时丢弃该条日志，后续 hook 不再执行，不作为错误报告
+var ErrDropLog = errors.New("log dropped by hook")
+
+type hook struct {
+	levels map[int]bool // nil 表示所有级别
+	f      func(log *Log) error
+}
+
+// HookError 为 hook 返回的错误或 hook 中的 panic
+type HookError struct {
+	Level int   // 日志级别
+	Index int   // hook 的执行顺序
+	Err   error // hook 返回的错误
+	Panic interface{}
+}
+
+func (e *HookError) Error() string {
+	if e.Panic != nil {
+		return fmt.Sprintf("log hook %d panic on %s log: %v", e.Index, LevelText(e.Level), e.Panic)
+	}
+	return fmt.Sprintf("log hook %d failed on %s log: %v", e.Index, LevelText(e.Level), e.Err)
+}
+
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
+
+// AddHook 添加在 levels 级别(为空时所有级别)的日志输出到 Writer/LogWriter 前执行的 hook，返回删除该 hook 的函数。
+// hook 按添加顺序执行，可以修改 log(如添加 Fields)，返回 ErrDropLog 时丢弃该条日志，
+// 返回其它错误或 panic 时交给 HookErrorHandler，日志照常输出。
+// hook 在日志方法所在的协程中执行，不持有 logger 的锁，不能再以相同级别写入该 logger；
+// Printf/Println 不经过 hook。
+// 与 ILogWriter 相同，日志写完后 log 会被回收复用，需要在 hook 返回后使用(如交给告警协程)时应复制 *log
+func (logger *Logger) AddHook(levels []int, f func(log *Log) error) (remove func()) {
+	h := &hook{f: f}
+	if len(levels) > 0 {
+		h.levels = make(map[int]bool, len(levels))
+		for _, lvl := range levels {
+			h.levels[lvl] = true
+		}
+	}
+
+	root := logger.root()
+	root.Lock()
+	defer root.Unlock()
+	var old []*hook
+	if p := root.hooks.Load(); p != nil {
+		old = *p
+	}
+	hooks := append(append(make([]*hook, 0, len(old)+1), old...), h)
+	root.hooks.Store(&hooks)
+
+	return func() {
+		root.Lock()
+		defer root.Unlock()
+		old := *root.hooks.Load()
+		hooks := make([]*hook, 0, len(old))
+		for _, v := range old {
+			if v != h {
+				hooks = append(hooks, v)
+			}
+		}
+		root.hooks.Store(&hooks)
+	}
+}
+
+func AddHook(levels []int, f func(log *Log) error) (remove func()) {
+	return DefaultLogger.AddHook(levels, f)
+}
+
+// runHooks 依次执行 hook，返回 false 表示日志被丢弃
+func (logger *Logger) runHooks(hooks []*hook, log *Log) bool {
+	for i, h := range hooks {
+		if h.levels != nil && !h.levels[log.Level] {
+			continue
+		}
+		err := callHook(h, log)
+		if err == nil {
+			continue
+		}
+		if errors.Is(err, ErrDropLog) {
+			return false
+		}
+		if e, ok := err.(*HookError); ok {
+			e.Index, e.Level = i, log.Level
+		} else {
+			err = &HookError{Level: log.Level, Index: i, Err: err}
+		}
+		logger.reportHookError(err)
+	}
+	return true
+}
+
+func callHook(h *hook, log *Log) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &HookError{Panic: r}
+		}
+	}()
+	return h.f(log)
+}
+
+// reportHookError 将错误交给 HookErrorHandler，未设置时输出到 stderr
+func (logger *Logger) reportHookError(err error) {
+	if logger.HookErrorHandler != nil {
+		logger.HookErrorHandler(err)
+	} else {
+		fmt.Fprintln(os.Stderr, err)
+	}
+}
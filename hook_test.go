@@ -0,0 +1,93 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestHooks(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := NewLogger()
+	logger.SetOutput(buf)
+	var hookErrs []error
+	logger.HookErrorHandler = func(err error) { hookErrs = append(hookErrs, err) }
+
+	var order []string
+	errorCount := 0
+	logger.AddHook(nil, func(log *Log) error {
+		order = append(order, "host")
+		log.Fields = append(log.Fields, F("host", "h1"))
+		return nil
+	})
+	logger.AddHook([]int{LEVEL_ERROR}, func(log *Log) error {
+		order = append(order, "count")
+		errorCount++
+		return nil
+	})
+	logger.AddHook(nil, func(log *Log) error {
+		order = append(order, "veto")
+		if strings.HasPrefix(log.Value, "secret") {
+			return ErrDropLog
+		}
+		return nil
+	})
+	removeFail := logger.AddHook([]int{LEVEL_WARN}, func(log *Log) error {
+		return errors.New("alert failed")
+	})
+	logger.AddHook([]int{LEVEL_WARN}, func(log *Log) error {
+		panic("boom")
+	})
+
+	child := logger.Named("c")
+	child.Info("a")
+	child.Error("b")
+	child.Info("secret c")
+	logger.Warn("d")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.HasSuffix(lines[0], "a host=h1") || !strings.HasSuffix(lines[1], "b host=h1") || !strings.HasSuffix(lines[2], "d host=h1") {
+		t.Fatalf("unexpected output: %q", buf.String())
+	}
+	if errorCount != 1 || strings.Join(order[:5], ",") != "host,veto,host,count,veto" {
+		t.Fatalf("unexpected hook order %v, count %d", order, errorCount)
+	}
+
+	var he *HookError
+	if len(hookErrs) != 2 || !errors.As(hookErrs[0], &he) || he.Index != 3 || he.Level != LEVEL_WARN || he.Err.Error() != "alert failed" {
+		t.Fatalf("unexpected hook errors: %v", hookErrs)
+	}
+	if !errors.As(hookErrs[1], &he) || he.Panic != "boom" || he.Index != 4 {
+		t.Fatalf("hook panic not reported: %v", hookErrs[1])
+	}
+
+	removeFail()
+	hookErrs = nil
+	logger.Warn("e")
+	if len(hookErrs) != 1 || !errors.As(hookErrs[0], &he) || he.Index != 3 {
+		t.Fatalf("unexpected hook errors after remove: %v", hookErrs)
+	}
+}
+
+func TestHookCopyLog(t *testing.T) {
+	logger := NewLogger()
+	logger.SetOutput(nil)
+
+	// hook 返回后 log 被回收，交给告警协程的须是副本
+	alerts := make(chan *Log, 1)
+	logger.AddHook([]int{LEVEL_ERROR}, func(log *Log) error {
+		l := *log
+		alerts <- &l
+		return nil
+	})
+	logger.ErrorKV("disk full", "dev", "sda")
+
+	l := <-alerts
+	if l.Value != "disk full" || l.Level != LEVEL_ERROR || len(l.Fields) != 1 || l.Fields[0].Key != "dev" {
+		t.Fatalf("unexpected alert: %+v", l)
+	}
+	if file, _ := l.Caller(false); file != "hook_test.go" {
+		t.Fatalf("unexpected caller: %q", file)
+	}
+}
@@ -0,0 +1,129 @@
+package log
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+type levelInfo struct {
+	name  string // ParseLevel 识别的名字
+	text  string // 日志中显示的文本
+	color string // ANSI 颜色，如 "\x1b[33m"
+	tag   string // 右对齐到 5 个字符的 text，见 levelTag
+	lower string // 小写的 text，用于 JSON、logfmt 等格式
+}
+
+func newLevelInfo(name, text, color string) levelInfo {
+	tag := text
+	if len(tag) < 5 {
+		tag = strings.Repeat(" ", 5-len(tag)) + tag
+	}
+	return levelInfo{name: name, text: text, color: color, tag: tag, lower: strings.ToLower(text)}
+}
+
+var (
+	levelsMu sync.Mutex
+	// levels 写时复制，读取不加锁
+	levels atomic.Pointer[map[int]levelInfo]
+
+	levelAliases = map[string]int{
+		"warning": LEVEL_WARN,
+		"off":     LEVEL_NONE,
+	}
+)
+
+func init() {
+	m := map[int]levelInfo{
+		LEVEL_PRINT: newLevelInfo("print", "Print", ""),
+		LEVEL_TRACE: newLevelInfo("trace", "Trace", "\x1b[90m"),
+		LEVEL_DEBUG: newLevelInfo("debug", "Debug", "\x1b[36m"),
+		LEVEL_INFO:  newLevelInfo("info", "Info", "\x1b[32m"),
+		LEVEL_WARN:  newLevelInfo("warn", "Warn", "\x1b[33m"),
+		LEVEL_ERROR: newLevelInfo("error", "Error", "\x1b[31m"),
+		LEVEL_PANIC: newLevelInfo("panic", "Panic", "\x1b[35m"),
+		LEVEL_FATAL: newLevelInfo("fatal", "Fatal", "\x1b[1;31m"),
+		LEVEL_NONE:  newLevelInfo("none", "None", ""),
+	}
+	levels.Store(&m)
+}
+
+// RegisterLevel 注册自定义级别，如 RegisterLevel(LEVEL_INFO+5, "notice", "Notice", "\x1b[34m")，
+// text 为空时使用 name，color 为彩色输出时使用的 ANSI 颜色
+func RegisterLevel(level int, name, text, color string) error {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return fmt.Errorf("log RegisterLevel Error: empty name")
+	}
+	if level <= LEVEL_PRINT || level >= LEVEL_NONE {
+		return fmt.Errorf("log RegisterLevel Error: Invalid Level - %d", level)
+	}
+	if text == "" {
+		text = name
+	}
+
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+	old := *levels.Load()
+	for k, v := range old {
+		if v.name == name && k != level {
+			return fmt.Errorf("log RegisterLevel Error: %q already registered as %d", name, k)
+		}
+	}
+	m := make(map[int]levelInfo, len(old)+1)
+	for k, v := range old {
+		m[k] = v
+	}
+	m[level] = newLevelInfo(name, text, color)
+	levels.Store(&m)
+	return nil
+}
+
+func lookupLevel(lvl int) (levelInfo, bool) {
+	info, ok := (*levels.Load())[lvl]
+	return info, ok
+}
+
+func LevelText(lvl int) string {
+	if info, ok := lookupLevel(lvl); ok {
+		return info.text
+	}
+	return "Unknown LVL"
+}
+
+func levelLowerText(lvl int) string {
+	if info, ok := lookupLevel(lvl); ok {
+		return info.lower
+	}
+	return "unknown lvl"
+}
+
+// LevelColor 返回级别的 ANSI 颜色，未设置时返回空串
+func LevelColor(lvl int) string {
+	info, _ := lookupLevel(lvl)
+	return info.color
+}
+
+// ParseLevel 按名字或显示文本解析级别，忽略大小写，也接受数字
+func ParseLevel(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	for lvl, info := range *levels.Load() {
+		if strings.EqualFold(info.name, s) || strings.EqualFold(info.text, s) {
+			return lvl, nil
+		}
+	}
+	if lvl, ok := levelAliases[strings.ToLower(s)]; ok {
+		return lvl, nil
+	}
+	if lvl, err := strconv.Atoi(s); err == nil && validLevel(lvl) {
+		return lvl, nil
+	}
+	return -1, fmt.Errorf("log ParseLevel Error: unknown level %q", s)
+}
+
+func validLevel(lvl int) bool {
+	_, ok := lookupLevel(lvl)
+	return ok
+}
@@ -0,0 +1,227 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type registeredLogger struct {
+	logger *Logger
+
+	// 临时修改级别时保存的原设置，到期后恢复
+	timer       *time.Timer
+	expires     time.Time
+	prevLevel   int
+	prevModules string
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = map[string]*registeredLogger{}
+)
+
+func init() {
+	RegisterLogger("default", DefaultLogger)
+}
+
+// RegisterLogger 注册 logger 以便通过 LevelHandler 按名字查看和修改级别，DefaultLogger 注册为 "default"
+func RegisterLogger(name string, logger *Logger) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if r, ok := registry[name]; ok && r.timer != nil {
+		r.timer.Stop()
+	}
+	registry[name] = &registeredLogger{logger: logger.root()}
+}
+
+func UnregisterLogger(name string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if r, ok := registry[name]; ok && r.timer != nil {
+		r.timer.Stop()
+	}
+	delete(registry, name)
+}
+
+// LevelState 是 LevelHandler 返回和接收的内容
+type LevelState struct {
+	Logger  string `json:"logger"`
+	Level   string `json:"level"`
+	Modules string `json:"modules,omitempty"`
+	// TTL 非空时为临时修改，到期后恢复原级别，如 "10m"
+	TTL     string `json:"ttl,omitempty"`
+	Expires string `json:"expires,omitempty"`
+}
+
+func (r *registeredLogger) state(name string) LevelState {
+	s := LevelState{
+		Logger:  name,
+		Level:   LevelText(r.logger.GetLevel()),
+		Modules: r.logger.ModuleLevels(),
+	}
+	if r.timer != nil {
+		s.Expires = r.expires.Format(time.RFC3339)
+	}
+	return s
+}
+
+// set 修改级别和模块级别，ttl > 0 时到期后恢复为第一次临时修改前的设置
+func (r *registeredLogger) set(level int, modules *string, ttl time.Duration) error {
+	prevLevel, prevModules := r.logger.GetLevel(), r.logger.ModuleLevels()
+	if modules != nil {
+		if err := r.logger.SetModuleLevels(*modules); err != nil {
+			return err
+		}
+	}
+	if level >= 0 {
+		r.logger.SetLevel(level)
+	}
+
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+		prevLevel, prevModules = r.prevLevel, r.prevModules
+	}
+	if ttl > 0 {
+		r.prevLevel, r.prevModules = prevLevel, prevModules
+		r.expires = time.Now().Add(ttl)
+		var timer *time.Timer
+		timer = time.AfterFunc(ttl, func() {
+			registryMu.Lock()
+			defer registryMu.Unlock()
+			if r.timer != timer {
+				return
+			}
+			r.timer = nil
+			r.logger.SetModuleLevels(r.prevModules)
+			r.logger.SetLevel(r.prevLevel)
+		})
+		r.timer = timer
+	}
+	return nil
+}
+
+type levelHandler struct{}
+
+// LevelHandler 返回查看和修改已注册 logger 级别的 http.Handler:
+//
+//	GET  ?logger=default          返回 JSON，Accept 为 text/plain 或 ?format=text 时只返回级别名
+//	GET  ?logger=*                返回所有已注册 logger
+//	PUT  ?logger=default&level=debug&modules=db=debug&ttl=10m
+//	PUT  {"level":"debug","modules":"db=debug","ttl":"10m"}，或 text/plain 的级别名
+func LevelHandler() http.Handler {
+	return levelHandler{}
+}
+
+func (levelHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	name := req.URL.Query().Get("logger")
+	if name == "" {
+		name = "default"
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if name == "*" && req.Method == http.MethodGet {
+		names := make([]string, 0, len(registry))
+		for k := range registry {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		states := make([]LevelState, 0, len(names))
+		for _, k := range names {
+			states = append(states, registry[k].state(k))
+		}
+		writeLevelResponse(w, req, http.StatusOK, states)
+		return
+	}
+
+	r, ok := registry[name]
+	if !ok {
+		http.Error(w, fmt.Sprintf("logger %q not registered", name), http.StatusNotFound)
+		return
+	}
+
+	switch req.Method {
+	case http.MethodGet:
+		writeLevelResponse(w, req, http.StatusOK, r.state(name))
+	case http.MethodPut, http.MethodPost:
+		s, err := readLevelRequest(req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		level := -1
+		if s.Level != "" {
+			if level, err = ParseLevel(s.Level); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		var ttl time.Duration
+		if s.TTL != "" {
+			if ttl, err = time.ParseDuration(s.TTL); err != nil || ttl < 0 {
+				http.Error(w, fmt.Sprintf("invalid ttl %q", s.TTL), http.StatusBadRequest)
+				return
+			}
+		}
+		var modules *string
+		if s.Modules != "" || req.URL.Query().Has("modules") {
+			modules = &s.Modules
+		}
+		if err = r.set(level, modules, ttl); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeLevelResponse(w, req, http.StatusOK, r.state(name))
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func readLevelRequest(req *http.Request) (LevelState, error) {
+	q := req.URL.Query()
+	s := LevelState{Level: q.Get("level"), Modules: q.Get("modules"), TTL: q.Get("ttl")}
+	body, err := io.ReadAll(io.LimitReader(req.Body, 64<<10))
+	if err != nil {
+		return s, err
+	}
+	body = []byte(strings.TrimSpace(string(body)))
+	if len(body) == 0 {
+		return s, nil
+	}
+	if body[0] == '{' {
+		if err = json.Unmarshal(body, &s); err != nil {
+			return s, err
+		}
+		return s, nil
+	}
+	s.Level = string(body)
+	return s, nil
+}
+
+func writeLevelResponse(w http.ResponseWriter, req *http.Request, code int, v interface{}) {
+	if req.URL.Query().Get("format") == "text" || strings.HasPrefix(req.Header.Get("Accept"), "text/plain") {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(code)
+		switch s := v.(type) {
+		case LevelState:
+			fmt.Fprintln(w, s.Level)
+		case []LevelState:
+			for _, v := range s {
+				fmt.Fprintf(w, "%s %s %s\n", v.Logger, v.Level, v.Modules)
+			}
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
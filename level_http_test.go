@@ -0,0 +1,99 @@
+package log
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLevelHandler(t *testing.T) {
+	logger := NewLogger()
+	logger.SetLevel(LEVEL_INFO)
+	RegisterLogger("test", logger)
+	defer UnregisterLogger("test")
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		LevelHandler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do("GET", "/?logger=test&format=text", ""); rec.Code != http.StatusOK || rec.Body.String() != "Info\n" {
+		t.Fatalf("GET: %d %q", rec.Code, rec.Body.String())
+	}
+
+	rec := do("PUT", "/?logger=test", `{"level":"debug","modules":"db=error"}`)
+	var s LevelState
+	json.Unmarshal(rec.Body.Bytes(), &s)
+	if rec.Code != http.StatusOK || s.Level != "Debug" || s.Modules != "db=error" || logger.GetLevel() != LEVEL_DEBUG {
+		t.Fatalf("PUT: %d %q", rec.Code, rec.Body.String())
+	}
+
+	if rec := do("PUT", "/?logger=test", "verbose"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("invalid level: %d", rec.Code)
+	}
+	if rec := do("PUT", "/?logger=nope", "warn"); rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown logger: %d", rec.Code)
+	}
+
+	// 临时修改到期后恢复
+	if rec := do("PUT", "/?logger=test&ttl=50ms&modules=", "error"); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "expires") {
+		t.Fatalf("PUT ttl: %d %q", rec.Code, rec.Body.String())
+	}
+	if logger.GetLevel() != LEVEL_ERROR || logger.ModuleLevels() != "" {
+		t.Fatalf("temporary level not applied")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		level, modules := logger.GetLevel(), logger.ModuleLevels()
+		if level == LEVEL_DEBUG && modules == "db=error" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("level not reverted: %d %q", level, modules)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if rec := do("GET", "/?logger=*", ""); !strings.Contains(rec.Body.String(), `"logger":"default"`) {
+		t.Fatalf("GET all: %q", rec.Body.String())
+	}
+}
+
+func TestLevelHandlerConcurrentLog(t *testing.T) {
+	logger := NewLogger()
+	logger.SetOutput(io.Discard)
+	RegisterLogger("race", logger)
+	defer UnregisterLogger("race")
+
+	// 日志写入与 HTTP 修改级别并发进行，由 -race 检查
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				logger.Info("x")
+			}
+		}
+	}()
+	for _, level := range []string{"warn", "debug", "error", "info"} {
+		req := httptest.NewRequest("PUT", "/?logger=race", strings.NewReader(level))
+		LevelHandler().ServeHTTP(httptest.NewRecorder(), req)
+	}
+	close(done)
+	wg.Wait()
+	if logger.GetLevel() != LEVEL_INFO {
+		t.Fatalf("level = %d", logger.GetLevel())
+	}
+}
@@ -0,0 +1,65 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	for s, want := range map[string]int{
+		"trace":   LEVEL_TRACE,
+		" INFO ":  LEVEL_INFO,
+		"Warning": LEVEL_WARN,
+		"off":     LEVEL_NONE,
+		"50":      LEVEL_ERROR,
+	} {
+		if lvl, err := ParseLevel(s); err != nil || lvl != want {
+			t.Fatalf("ParseLevel(%q) = %d, %v", s, lvl, err)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Fatalf("unknown level should fail")
+	}
+
+	logger := NewLogger()
+	if err := logger.SetLevel(12345); err == nil || logger.GetLevel() != DefaultLogLevel {
+		t.Fatalf("invalid SetLevel should return error and keep level")
+	}
+}
+
+func TestCustomLevel(t *testing.T) {
+	const LEVEL_NOTICE = LEVEL_INFO + 5
+	if err := RegisterLevel(LEVEL_NOTICE, "notice", "Notice", "\x1b[34m"); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterLevel(LEVEL_NOTICE+1, "notice", "", ""); err == nil {
+		t.Fatalf("duplicated name should fail")
+	}
+
+	buf := &bytes.Buffer{}
+	logger := NewLogger()
+	logger.SetOutput(buf)
+	logger.SetLevel(LEVEL_TRACE)
+
+	logger.Trace("t")
+	logger.Logf(LEVEL_NOTICE, "n %d", 1)
+	logger.SetFormater(NewJSONFormater().Format)
+	logger.LogKV(LEVEL_NOTICE, "j")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[0], " [Trace] ") || !strings.Contains(lines[1], " [Notice] ") || !strings.Contains(lines[2], `"level":"notice"`) {
+		t.Fatalf("unexpected output: %q", buf.String())
+	}
+	if lvl, _ := ParseLevel("NOTICE"); lvl != LEVEL_NOTICE || LevelColor(LEVEL_NOTICE) != "\x1b[34m" {
+		t.Fatalf("custom level not registered")
+	}
+
+	s := LogWithFormater(LEVEL_NOTICE, 1, DefaultLogTimeLayout, "n %d", 2)
+	if want := " [Notice] " + prevLine() + " n 2"; !strings.HasSuffix(s, want) {
+		t.Fatalf("LogWithFormater = %q, want suffix %q", s, want)
+	}
+	if s := LogWithFormater(LEVEL_TRACE, 1, DefaultLogTimeLayout, "t"); !strings.Contains(s, " [Trace] [level_test.go:") {
+		t.Fatalf("unexpected LogWithFormater: %q", s)
+	}
+}
@@ -4,16 +4,19 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"os"
 	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 )
 
+// 级别之间留有间隔，用于 RegisterLevel 注册自定义级别
 const (
-	LEVEL_PRINT = iota
+	LEVEL_PRINT = iota * 10
+	LEVEL_TRACE
 	LEVEL_DEBUG
 	LEVEL_INFO
 	LEVEL_WARN
@@ -33,6 +36,7 @@ var (
 	DefaultLogDepth      = 2
 	DefaultLogWriter     = os.Stdout
 	DefaultLogTimeLayout = "2006-01-02 15:04:05.000"
+	DefaultLogColor      = COLOR_NEVER
 
 	filepaths = []string{}
 
@@ -99,75 +103,123 @@ func init() {
 	}
 
 	// fmt.Println("--- filepaths:", filepaths)
-
-	DefaultLogger.depth = DefaultLogDepth + 1
-}
-
-func LevelText(lvl int) string {
-	switch lvl {
-	case LEVEL_PRINT:
-		return "Print"
-	case LEVEL_DEBUG:
-		return "Debug"
-	case LEVEL_INFO:
-		return "Info"
-	case LEVEL_WARN:
-		return "Warn"
-	case LEVEL_ERROR:
-		return "Error"
-	case LEVEL_PANIC:
-		return "Panic"
-	case LEVEL_FATAL:
-		return "Fatal"
-	case LEVEL_NONE:
-	default:
-	}
-	return "Unknown LVL"
 }
 
 type Log struct {
 	Now    time.Time `json:"Now"`
 	Depth  int       `json:"Depth"`
+	PC     uintptr   `json:"-"` // 日志调用位置，通过 Caller 解析为 File/Line
 	Level  int       `json:"Level"`
 	Line   int       `json:"Line"`
 	File   string    `json:"File"`
 	Value  string    `json:"Value"`
+	Name   string    `json:"Name,omitempty"`
+	Fields []Field   `json:"Fields,omitempty"`
+	Stack  string    `json:"Stack,omitempty"`
 	Logger *Logger   `json:"-"`
+
+	// Caller 解析出的调用位置，保存短路径和完整路径，供不同 FullPath 设置的格式化函数选择
+	frame *callerFrame
 }
 
+// ILogWriter 的 WriteLog 返回后 log 会被回收复用，需要在返回后使用时应复制 *log
 type ILogWriter interface {
 	WriteLog(log *Log) (n int, err error)
 }
 
-type LogWriter struct {
-	writers []ILogWriter
+// Syncer 由需要落盘的 Writer/LogWriter 实现，如 *os.File、*FileWriter
+type Syncer interface {
+	Sync() error
+}
+
+// syncWriter 调用 w 的 Sync，忽略终端、管道等不支持落盘的文件返回的 EINVAL/ENOTSUP
+func syncWriter(w interface{}) error {
+	if s, ok := w.(Syncer); ok {
+		if err := s.Sync(); err != nil && !errors.Is(err, syscall.EINVAL) && !errors.Is(err, syscall.ENOTSUP) {
+			return err
+		}
+	}
+	return nil
+}
+
+type syncMultiWriter struct {
+	writers []io.Writer
 }
 
-func (w *LogWriter) WriteLog(log *Log) (n int, err error) {
+func (w *syncMultiWriter) Write(p []byte) (n int, err error) {
 	for _, v := range w.writers {
-		v.WriteLog(log)
+		if n, err = v.Write(p); err != nil {
+			return n, err
+		}
+		if n != len(p) {
+			return n, io.ErrShortWrite
+		}
 	}
-	return 0, nil
+	return len(p), nil
 }
 
-func MultiLogWriter(writers ...ILogWriter) ILogWriter {
-	w := &LogWriter{}
-	for _, v := range writers {
-		w.writers = append(w.writers, v.(ILogWriter))
+func (w *syncMultiWriter) Sync() error {
+	var errs []error
+	for _, v := range w.writers {
+		if err := syncWriter(v); err != nil {
+			errs = append(errs, err)
+		}
 	}
-	return w
+	return errors.Join(errs...)
 }
 
+// MultiWriter 与 io.MultiWriter 相同，并实现 Syncer，Sync 时依次调用各 Writer 的 Sync，
+// 用于 SetOutput 时 Sync 和 Panic/Fatal 能将其中的 FileWriter 落盘
+func MultiWriter(writers ...io.Writer) io.Writer {
+	return &syncMultiWriter{writers: append([]io.Writer(nil), writers...)}
+}
+
+// Logger 的导出字段和锁只在根 logger 上有效，With/Named/Ctx/WithCallerSkip 派生出的子 logger 的导出字段不使用，
+// 应通过 SetOutput、SetFormater 等方法修改，子 logger 的 Lock/Unlock 锁定根 logger
 type Logger struct {
 	sync.Mutex
 	Writer    io.Writer
 	LogWriter ILogWriter
 	depth     int
-	Level     int
 	Layout    string
-	Formater  func(log *Log) string
-	FullPath  bool
+	// Formater 为空时使用默认的文本格式(直接追加到 buf，不分配 string)，可直接赋值或通过 SetFormater 设置
+	Formater func(log *Log) string
+	// AppendFormater 将日志追加到 buf，非空时优先于 Formater，默认为空，通过 SetAppendFormater 设置
+	AppendFormater func(buf []byte, log *Log) []byte
+	FullPath       bool
 	// filepaths []string
+
+	// Color 为默认格式化的彩色输出模式 COLOR_*，通过 SetColor 设置
+	Color   int
+	colored bool
+
+	// StackLevel 及以上级别的日志记录调用栈，0 表示不记录，通过 SetStackTrace 设置
+	StackLevel int
+	StackDepth int
+	StackSkip  int
+
+	// ExitFunc 为 Fatal 写完日志后的退出函数，默认 os.Exit，测试中可替换
+	ExitFunc func(code int)
+
+	// HookErrorHandler 接收 hook 返回的 *HookError，未设置时输出到 stderr
+	HookErrorHandler func(err error)
+	// WriteErrorHandler 接收 Writer 和 LogWriter 返回的错误(如 MultiLogWriter 合并的 *WriterError)，未设置时忽略，
+	// 处理 Writer 的错误时持有 logger 的锁，不能在其中再写入该 logger
+	WriteErrorHandler func(err error)
+
+	// SetLevel 设置的级别，HTTP 等并发修改时原子读写
+	level atomic.Int32
+	// SetModuleLevels 设置的按模块级别
+	modules atomic.Pointer[moduleLevels]
+	// AddHook 添加的 hook，写时复制
+	hooks atomic.Pointer[[]*hook]
+	// SetSampling 设置的采样和限流
+	sampler atomic.Pointer[sampler]
+
+	// With/Named 派生出的子 logger 通过 parent 共享输出、级别和锁
+	parent *Logger
+	name   string
+	fields []Field
 }
 
 // func (logger *Logger) AddFileIgnorePath(path string) {
@@ -186,270 +238,489 @@ type Logger struct {
 // 	}
 // }
 
-func (logger *Logger) Printf(format string, v ...interface{}) {
-	logger.Lock()
-	if logger.Writer != nil {
-		fmt.Fprintf(logger.Writer, fmt.Sprintf(format, v...))
+func (logger *Logger) root() *Logger {
+	for logger.parent != nil {
+		logger = logger.parent
 	}
-	if logger.LogWriter != nil {
-		log := &Log{
-			Depth:  logger.depth,
-			Level:  LEVEL_PRINT,
-			Value:  fmt.Sprintf(format, v...),
-			Logger: logger,
-		}
-		logger.LogWriter.WriteLog(log)
+	return logger
+}
+
+// Lock 锁定根 logger，子 logger 与根 logger 共享同一把锁
+func (logger *Logger) Lock() {
+	logger.root().Mutex.Lock()
+}
+
+func (logger *Logger) Unlock() {
+	logger.root().Mutex.Unlock()
+}
+
+func (logger *Logger) child() *Logger {
+	return &Logger{
+		depth:  logger.depth,
+		parent: logger.root(),
+		name:   logger.name,
+		fields: logger.fields,
 	}
-	logger.Unlock()
 }
 
-func (logger *Logger) Println(v ...interface{}) {
-	logger.Lock()
-	if logger.Writer != nil {
-		fmt.Fprintln(logger.Writer, v...)
+// With 返回携带固定字段的子 logger，子 logger 与父 logger 共享输出、级别和锁
+func (logger *Logger) With(kv ...interface{}) *Logger {
+	fields := Fields(kv...)
+	child := logger.child()
+	child.fields = make([]Field, 0, len(logger.fields)+len(fields))
+	child.fields = append(child.fields, logger.fields...)
+	child.fields = append(child.fields, fields...)
+	return child
+}
+
+// Named 返回带名字的子 logger，多级命名以 "." 连接，如 "db.pool"
+func (logger *Logger) Named(name string) *Logger {
+	child := logger.child()
+	if logger.name != "" && name != "" {
+		child.name = logger.name + "." + name
+	} else if name != "" {
+		child.name = name
+	}
+	return child
+}
+
+func (logger *Logger) Name() string {
+	return logger.name
+}
+
+func (logger *Logger) withFields(kv []interface{}) []Field {
+	if len(kv) == 0 {
+		return logger.fields
 	}
-	if logger.LogWriter != nil {
-		log := &Log{
-			Depth:  logger.depth,
+	fields := Fields(kv...)
+	if len(logger.fields) == 0 {
+		return fields
+	}
+	return append(append(make([]Field, 0, len(logger.fields)+len(fields)), logger.fields...), fields...)
+}
+
+func (logger *Logger) print(value string) {
+	root := logger.root()
+	root.Lock()
+	if root.Writer != nil {
+		fmt.Fprint(root.Writer, value)
+	}
+	if root.LogWriter != nil {
+		log := getLog()
+		*log = Log{
+			Depth:  logger.depth + 1,
+			PC:     callerPC(logger.depth),
 			Level:  LEVEL_PRINT,
-			Value:  fmt.Sprintln(v...),
-			Logger: logger,
+			Value:  value,
+			Name:   logger.name,
+			Fields: logger.fields,
+			Logger: root,
 		}
-		logger.LogWriter.WriteLog(log)
+		root.LogWriter.WriteLog(log)
+		putLog(log)
 	}
-	logger.Unlock()
+	root.Unlock()
 }
 
-func (logger *Logger) Debug(format string, v ...interface{}) {
-	if LEVEL_DEBUG >= logger.Level {
-		logger.Lock()
-		now := time.Now()
-		log := &Log{
-			Now:    now,
-			Depth:  logger.depth,
-			Level:  LEVEL_DEBUG,
-			Value:  fmt.Sprintf(format, v...),
-			Logger: logger,
+// output 输出一条日志，skip 为日志方法之上额外跳过的调用层数，format 为格式串或 KV 方法的 msg，用于采样分组，
+// args 为格式化参数或 kv，StackLevel 以上级别时优先使用其中 error 携带的调用栈
+func (logger *Logger) output(skip, level int, format, value string, kv []interface{}, args []interface{}) string {
+	root := logger.root()
+	pc := callerPC(logger.depth + skip)
+	if !root.sample(level, pc, format) {
+		return ""
+	}
+
+	log := getLog()
+	*log = Log{
+		Now:    time.Now(),
+		Depth:  logger.depth + skip + 2,
+		PC:     pc,
+		Level:  level,
+		Value:  value,
+		Name:   logger.name,
+		Fields: logger.withFields(kv),
+	}
+	if root.StackLevel > 0 && level >= root.StackLevel {
+		if log.Stack = argsStack(args); log.Stack == "" {
+			log.Stack = captureStack(logger.depth+skip+root.StackSkip, root.StackDepth)
+		}
+	}
+	s := logger.writeLog(log)
+	putLog(log)
+	return s
+}
+
+// writeLog 执行 hook 后格式化并输出 log，返回格式化后的文本(仅在设置了 Writer 或为 LEVEL_PANIC 时格式化)
+func (logger *Logger) writeLog(log *Log) string {
+	var (
+		s    string
+		root = logger.root()
+	)
+	log.Logger = root
+	if hooks := root.hooks.Load(); hooks != nil && len(*hooks) > 0 && !root.runHooks(*hooks, log) {
+		// 被丢弃的 Panic 日志仍以原消息 panic
+		return log.Value
+	}
+	root.Lock()
+	if root.Writer != nil || log.Level == LEVEL_PANIC {
+		buf := getBuffer()
+		// 格式化函数须由 writeLog 直接调用，使自定义 Formater 与 ILogWriter 中的 log.Depth 一致
+		switch {
+		case root.AppendFormater != nil:
+			*buf = root.AppendFormater(*buf, log)
+		case root.Formater == nil:
+			*buf = root.appendDefaultLog(*buf, log)
+		default:
+			*buf = append(*buf, root.Formater(log)...)
 		}
-		if logger.Writer != nil {
-			fmt.Fprintln(logger.Writer, logger.Formater(log))
+		if log.Level == LEVEL_PANIC {
+			s = string(*buf)
 		}
-		logger.Unlock()
-		if logger.LogWriter != nil {
-			logger.LogWriter.WriteLog(log)
+		if root.Writer != nil {
+			*buf = append(*buf, '\n')
+			if _, err := root.Writer.Write(*buf); err != nil && root.WriteErrorHandler != nil {
+				root.WriteErrorHandler(err)
+			}
+		}
+		putBuffer(buf)
+	}
+	root.Unlock()
+	if root.LogWriter != nil {
+		if _, err := root.LogWriter.WriteLog(log); err != nil && root.WriteErrorHandler != nil {
+			root.WriteErrorHandler(err)
 		}
 	}
+	return s
+}
+
+// Sync 将 Writer 和 LogWriter 中缓冲的日志落盘
+func (logger *Logger) Sync() error {
+	root := logger.root()
+	root.Lock()
+	out, lw := root.Writer, root.LogWriter
+	root.Unlock()
+	var errs []error
+	if err := syncWriter(out); err != nil {
+		errs = append(errs, err)
+	}
+	if err := syncWriter(lw); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+func (logger *Logger) panic(s string) {
+	logger.Sync()
+	panic(errors.New(s))
+}
+
+func (logger *Logger) exit(code int) {
+	logger.Sync()
+	if exit := logger.root().ExitFunc; exit != nil {
+		exit(code)
+	} else {
+		os.Exit(code)
+	}
+}
+
+// enabled 判断 level 是否需要输出，须由日志方法直接调用，以便按 depth+skip 找到调用位置匹配模块级别
+func (logger *Logger) enabled(skip, level int) bool {
+	root := logger.root()
+	m := root.modules.Load()
+	if m == nil {
+		return level >= int(root.level.Load())
+	}
+	var pcs [1]uintptr
+	runtime.Callers(logger.depth+skip+1, pcs[:])
+	return level >= m.level(logger.name, pcs[0], int(root.level.Load()))
+}
+
+// enabledPC 按 logger 名字和给定的调用位置 pc 判断级别，pc 为 0 时只匹配名字
+func (logger *Logger) enabledPC(level int, pc uintptr) bool {
+	root := logger.root()
+	m := root.modules.Load()
+	if m == nil {
+		return level >= int(root.level.Load())
+	}
+	return level >= m.level(logger.name, pc, int(root.level.Load()))
+}
+
+func (logger *Logger) Printf(format string, v ...interface{}) {
+	logger.print(fmt.Sprintf(format, v...))
+}
+
+func (logger *Logger) Println(v ...interface{}) {
+	logger.print(fmt.Sprintln(v...))
+}
+
+func (logger *Logger) Trace(format string, v ...interface{}) {
+	if logger.enabled(0, LEVEL_TRACE) {
+		logger.output(0, LEVEL_TRACE, format, fmt.Sprintf(format, v...), nil, v)
+	}
+}
+
+func (logger *Logger) Debug(format string, v ...interface{}) {
+	if logger.enabled(0, LEVEL_DEBUG) {
+		logger.output(0, LEVEL_DEBUG, format, fmt.Sprintf(format, v...), nil, v)
+	}
 }
 
 func (logger *Logger) Info(format string, v ...interface{}) {
-	if LEVEL_INFO >= logger.Level {
-		logger.Lock()
-		now := time.Now()
-		log := &Log{
-			Now:    now,
-			Depth:  logger.depth,
-			Level:  LEVEL_INFO,
-			Value:  fmt.Sprintf(format, v...),
-			Logger: logger,
-		}
-		if logger.Writer != nil {
-			fmt.Fprintln(logger.Writer, logger.Formater(log))
-		}
-		logger.Unlock()
-		if logger.LogWriter != nil {
-			logger.LogWriter.WriteLog(log)
-		}
+	if logger.enabled(0, LEVEL_INFO) {
+		logger.output(0, LEVEL_INFO, format, fmt.Sprintf(format, v...), nil, v)
 	}
 }
 
 func (logger *Logger) Warn(format string, v ...interface{}) {
-	if LEVEL_WARN >= logger.Level {
-		logger.Lock()
-		now := time.Now()
-		log := &Log{
-			Now:    now,
-			Depth:  logger.depth,
-			Level:  LEVEL_WARN,
-			Value:  fmt.Sprintf(format, v...),
-			Logger: logger,
-		}
-		if logger.Writer != nil {
-			fmt.Fprintln(logger.Writer, logger.Formater(log))
-		}
-		logger.Unlock()
-		if logger.LogWriter != nil {
-			logger.LogWriter.WriteLog(log)
-		}
+	if logger.enabled(0, LEVEL_WARN) {
+		logger.output(0, LEVEL_WARN, format, fmt.Sprintf(format, v...), nil, v)
 	}
 }
 
 func (logger *Logger) Error(format string, v ...interface{}) {
-	if LEVEL_ERROR >= logger.Level {
-		logger.Lock()
-		now := time.Now()
-		log := &Log{
-			Now:    now,
-			Depth:  logger.depth,
-			Level:  LEVEL_ERROR,
-			Value:  fmt.Sprintf(format, v...),
-			Logger: logger,
-		}
-		if logger.Writer != nil {
-			fmt.Fprintln(logger.Writer, logger.Formater(log))
-		}
-		logger.Unlock()
-		if logger.LogWriter != nil {
-			logger.LogWriter.WriteLog(log)
-		}
+	if logger.enabled(0, LEVEL_ERROR) {
+		logger.output(0, LEVEL_ERROR, format, fmt.Sprintf(format, v...), nil, v)
 	}
 }
 
 func (logger *Logger) Panic(format string, v ...interface{}) {
-	if LEVEL_PANIC >= logger.Level {
-		logger.Lock()
-		now := time.Now()
-		log := &Log{
-			Now:    now,
-			Depth:  logger.depth,
-			Level:  LEVEL_PANIC,
-			Value:  fmt.Sprintf(format, v...),
-			Logger: logger,
-		}
-		s := logger.Formater(log)
-		if logger.Writer != nil {
-			fmt.Fprintln(logger.Writer, s)
-		}
-		logger.Unlock()
-		if logger.LogWriter != nil {
-			logger.LogWriter.WriteLog(log)
-		}
-		panic(errors.New(s))
+	if logger.enabled(0, LEVEL_PANIC) {
+		logger.panic(logger.output(0, LEVEL_PANIC, format, fmt.Sprintf(format, v...), nil, v))
 	}
 }
 
 func (logger *Logger) Fatal(format string, v ...interface{}) {
-	if LEVEL_FATAL >= logger.Level {
-		logger.Lock()
-		now := time.Now()
-		log := &Log{
-			Now:    now,
-			Depth:  logger.depth,
-			Level:  LEVEL_FATAL,
-			Value:  fmt.Sprintf(format, v...),
-			Logger: logger,
-		}
-		if logger.Writer != nil {
-			fmt.Fprintln(logger.Writer, logger.Formater(log))
-		}
-		logger.Unlock()
-		if logger.LogWriter != nil {
-			logger.LogWriter.WriteLog(log)
-		}
-		os.Exit(-1)
+	if logger.enabled(0, LEVEL_FATAL) {
+		logger.output(0, LEVEL_FATAL, format, fmt.Sprintf(format, v...), nil, v)
+		logger.exit(-1)
 	}
 }
 
-func (logger *Logger) SetLevel(level int) {
-	if level >= 0 && level <= LEVEL_NONE {
-		logger.Level = level
-	} else {
-		log.Fatal(fmt.Errorf("log SetLogLevel Error: Invalid Level - %d\n", level))
+// Logf 以任意级别(包括 RegisterLevel 注册的级别)输出日志
+func (logger *Logger) Logf(level int, format string, v ...interface{}) {
+	if logger.enabled(0, level) {
+		logger.output(0, level, format, fmt.Sprintf(format, v...), nil, v)
+	}
+}
+
+func (logger *Logger) LogKV(level int, msg string, kv ...interface{}) {
+	if logger.enabled(0, level) {
+		logger.output(0, level, msg, msg, kv, kv)
+	}
+}
+
+func (logger *Logger) TraceKV(msg string, kv ...interface{}) {
+	if logger.enabled(0, LEVEL_TRACE) {
+		logger.output(0, LEVEL_TRACE, msg, msg, kv, kv)
+	}
+}
+
+func (logger *Logger) DebugKV(msg string, kv ...interface{}) {
+	if logger.enabled(0, LEVEL_DEBUG) {
+		logger.output(0, LEVEL_DEBUG, msg, msg, kv, kv)
+	}
+}
+
+func (logger *Logger) InfoKV(msg string, kv ...interface{}) {
+	if logger.enabled(0, LEVEL_INFO) {
+		logger.output(0, LEVEL_INFO, msg, msg, kv, kv)
+	}
+}
+
+func (logger *Logger) WarnKV(msg string, kv ...interface{}) {
+	if logger.enabled(0, LEVEL_WARN) {
+		logger.output(0, LEVEL_WARN, msg, msg, kv, kv)
+	}
+}
+
+func (logger *Logger) ErrorKV(msg string, kv ...interface{}) {
+	if logger.enabled(0, LEVEL_ERROR) {
+		logger.output(0, LEVEL_ERROR, msg, msg, kv, kv)
+	}
+}
+
+func (logger *Logger) PanicKV(msg string, kv ...interface{}) {
+	if logger.enabled(0, LEVEL_PANIC) {
+		logger.panic(logger.output(0, LEVEL_PANIC, msg, msg, kv, kv))
+	}
+}
+
+func (logger *Logger) FatalKV(msg string, kv ...interface{}) {
+	if logger.enabled(0, LEVEL_FATAL) {
+		logger.output(0, LEVEL_FATAL, msg, msg, kv, kv)
+		logger.exit(-1)
+	}
+}
+
+func (logger *Logger) SetLevel(level int) error {
+	if !validLevel(level) {
+		return fmt.Errorf("log SetLogLevel Error: Invalid Level - %d", level)
 	}
+	logger.root().level.Store(int32(level))
+	return nil
+}
+
+// GetLevel 返回 SetLevel 设置的级别
+func (logger *Logger) GetLevel() int {
+	return int(logger.root().level.Load())
 }
 
 func (logger *Logger) SetOutput(out io.Writer) {
-	logger.Writer = out
+	root := logger.root()
+	root.Writer = out
+	root.colored = colorEnabled(root.Color, out)
 }
 
 func (logger *Logger) SetStructOutput(out ILogWriter) {
-	logger.LogWriter = out
+	logger.root().LogWriter = out
 }
 
+// SetFormater 设置返回 string 的格式化函数，同时清除 AppendFormater，f 为 nil 时恢复默认格式
 func (logger *Logger) SetFormater(f func(log *Log) string) {
-	logger.Formater = f
+	root := logger.root()
+	root.Formater = f
+	root.AppendFormater = nil
 }
 
-func (logger *Logger) defaultLogFormater(log *Log) string {
-	_, file, line, ok := runtime.Caller(log.Depth)
-	if !ok {
-		file = "???"
-		line = -1
-	} else {
-		if logger.FullPath {
-			for _, v := range filepaths {
-				tmp := strings.Replace(file, v, "", 1)
-				if tmp != file {
-					file = tmp
-					break
-				}
-			}
-		} else {
-			pos := strings.LastIndex(file, "/")
-			if pos >= 0 {
-				file = file[pos+1:]
-			}
-		}
-	}
+// SetAppendFormater 设置追加到 []byte 的格式化函数，如 NewJSONFormater().Append，可避免每条日志分配 string
+func (logger *Logger) SetAppendFormater(f func(buf []byte, log *Log) []byte) {
+	logger.root().AppendFormater = f
+}
 
-	log.File = file
-	log.Line = line
-	switch log.Level {
-	case LEVEL_DEBUG:
-		return strings.Join([]string{log.Now.Format(logger.Layout), fmt.Sprintf(" [Debug] [%s:%d] ", file, line), log.Value}, "")
-	case LEVEL_INFO:
-		return strings.Join([]string{log.Now.Format(logger.Layout), fmt.Sprintf(" [ Info] [%s:%d] ", file, line), log.Value}, "")
-	case LEVEL_WARN:
-		return strings.Join([]string{log.Now.Format(logger.Layout), fmt.Sprintf(" [ Warn] [%s:%d] ", file, line), log.Value}, "")
-	case LEVEL_ERROR:
-		return strings.Join([]string{log.Now.Format(logger.Layout), fmt.Sprintf(" [Error] [%s:%d] ", file, line), log.Value}, "")
-	case LEVEL_PANIC:
-		return strings.Join([]string{log.Now.Format(logger.Layout), fmt.Sprintf(" [Panic] [%s:%d] ", file, line), log.Value}, "")
-	case LEVEL_FATAL:
-		return strings.Join([]string{log.Now.Format(logger.Layout), fmt.Sprintf(" [Fatal] [%s:%d] ", file, line), log.Value}, "")
-	default:
+func (logger *Logger) appendDefaultLog(buf []byte, log *Log) []byte {
+	log.Caller(logger.FullPath)
+	if logger.colored {
+		return appendColorText(buf, log, logger.Layout)
 	}
-	return ""
+	return appendText(buf, log, logger.Layout)
 }
 
 func (logger *Logger) SetLogTimeFormat(layout string) {
-	logger.Layout = layout
+	logger.root().Layout = layout
 }
 
 /********* default logger *********/
 func Printf(fmtstr string, v ...interface{}) {
-	DefaultLogger.Printf(fmtstr, v...)
+	DefaultLogger.print(fmt.Sprintf(fmtstr, v...))
 }
 
 func Println(v ...interface{}) {
-	DefaultLogger.Println(v...)
+	DefaultLogger.print(fmt.Sprintln(v...))
+}
+
+func Trace(format string, v ...interface{}) {
+	if DefaultLogger.enabled(0, LEVEL_TRACE) {
+		DefaultLogger.output(0, LEVEL_TRACE, format, fmt.Sprintf(format, v...), nil, v)
+	}
 }
 
 func Debug(format string, v ...interface{}) {
-	DefaultLogger.Debug(format, v...)
+	if DefaultLogger.enabled(0, LEVEL_DEBUG) {
+		DefaultLogger.output(0, LEVEL_DEBUG, format, fmt.Sprintf(format, v...), nil, v)
+	}
 }
 
 func Info(format string, v ...interface{}) {
-	DefaultLogger.Info(format, v...)
+	if DefaultLogger.enabled(0, LEVEL_INFO) {
+		DefaultLogger.output(0, LEVEL_INFO, format, fmt.Sprintf(format, v...), nil, v)
+	}
 }
 
 func Warn(format string, v ...interface{}) {
-	DefaultLogger.Warn(format, v...)
+	if DefaultLogger.enabled(0, LEVEL_WARN) {
+		DefaultLogger.output(0, LEVEL_WARN, format, fmt.Sprintf(format, v...), nil, v)
+	}
 }
 
 func Error(format string, v ...interface{}) {
-	DefaultLogger.Error(format, v...)
+	if DefaultLogger.enabled(0, LEVEL_ERROR) {
+		DefaultLogger.output(0, LEVEL_ERROR, format, fmt.Sprintf(format, v...), nil, v)
+	}
 }
 
 func Panic(format string, v ...interface{}) {
-	DefaultLogger.Panic(format, v...)
+	if DefaultLogger.enabled(0, LEVEL_PANIC) {
+		DefaultLogger.panic(DefaultLogger.output(0, LEVEL_PANIC, format, fmt.Sprintf(format, v...), nil, v))
+	}
 }
 
 func Fatal(format string, v ...interface{}) {
-	DefaultLogger.Fatal(format, v...)
+	if DefaultLogger.enabled(0, LEVEL_FATAL) {
+		DefaultLogger.output(0, LEVEL_FATAL, format, fmt.Sprintf(format, v...), nil, v)
+		DefaultLogger.exit(-1)
+	}
 }
 
-func SetLevel(level int) {
-	DefaultLogger.SetLevel(level)
+func Logf(level int, format string, v ...interface{}) {
+	if DefaultLogger.enabled(0, level) {
+		DefaultLogger.output(0, level, format, fmt.Sprintf(format, v...), nil, v)
+	}
+}
+
+func LogKV(level int, msg string, kv ...interface{}) {
+	if DefaultLogger.enabled(0, level) {
+		DefaultLogger.output(0, level, msg, msg, kv, kv)
+	}
+}
+
+func TraceKV(msg string, kv ...interface{}) {
+	if DefaultLogger.enabled(0, LEVEL_TRACE) {
+		DefaultLogger.output(0, LEVEL_TRACE, msg, msg, kv, kv)
+	}
+}
+
+func DebugKV(msg string, kv ...interface{}) {
+	if DefaultLogger.enabled(0, LEVEL_DEBUG) {
+		DefaultLogger.output(0, LEVEL_DEBUG, msg, msg, kv, kv)
+	}
+}
+
+func InfoKV(msg string, kv ...interface{}) {
+	if DefaultLogger.enabled(0, LEVEL_INFO) {
+		DefaultLogger.output(0, LEVEL_INFO, msg, msg, kv, kv)
+	}
+}
+
+func WarnKV(msg string, kv ...interface{}) {
+	if DefaultLogger.enabled(0, LEVEL_WARN) {
+		DefaultLogger.output(0, LEVEL_WARN, msg, msg, kv, kv)
+	}
+}
+
+func ErrorKV(msg string, kv ...interface{}) {
+	if DefaultLogger.enabled(0, LEVEL_ERROR) {
+		DefaultLogger.output(0, LEVEL_ERROR, msg, msg, kv, kv)
+	}
+}
+
+func PanicKV(msg string, kv ...interface{}) {
+	if DefaultLogger.enabled(0, LEVEL_PANIC) {
+		DefaultLogger.panic(DefaultLogger.output(0, LEVEL_PANIC, msg, msg, kv, kv))
+	}
+}
+
+func FatalKV(msg string, kv ...interface{}) {
+	if DefaultLogger.enabled(0, LEVEL_FATAL) {
+		DefaultLogger.output(0, LEVEL_FATAL, msg, msg, kv, kv)
+		DefaultLogger.exit(-1)
+	}
+}
+
+func With(kv ...interface{}) *Logger {
+	return DefaultLogger.With(kv...)
+}
+
+func Named(name string) *Logger {
+	return DefaultLogger.Named(name)
+}
+
+func SetLevel(level int) error {
+	return DefaultLogger.SetLevel(level)
+}
+
+func GetLevel() int {
+	return DefaultLogger.GetLevel()
 }
 
 func SetOutput(out io.Writer) {
@@ -464,6 +735,10 @@ func SetFormater(f func(log *Log) string) {
 	DefaultLogger.SetFormater(f)
 }
 
+func SetAppendFormater(f func(buf []byte, log *Log) []byte) {
+	DefaultLogger.SetAppendFormater(f)
+}
+
 func SetLogTimeFormat(layout string) {
 	DefaultLogger.SetLogTimeFormat(layout)
 }
@@ -482,46 +757,29 @@ func SetBuildDir(dir string) {
 	}
 }
 
+// LogWithFormater 按默认文本格式返回一条日志，depth 同 runtime.Caller，支持 RegisterLevel 注册的级别，
+// LEVEL_PRINT 及未注册的级别返回空串
 func LogWithFormater(lvl int, depth int, layout string, format string, v ...interface{}) string {
-	now := time.Now()
-	_, file, line, ok := runtime.Caller(depth)
-	if !ok {
-		file = "???"
-		line = -1
-	} else {
-		pos := strings.LastIndex(file, "/")
-		if pos >= 0 {
-			file = file[pos+1:]
-		}
-	}
-
-	switch lvl {
-	case LEVEL_DEBUG:
-		return strings.Join([]string{now.Format(layout), fmt.Sprintf(" [Debug] [%s:%d] ", file, line), fmt.Sprintf(format, v...)}, "")
-	case LEVEL_INFO:
-		return strings.Join([]string{now.Format(layout), fmt.Sprintf(" [ Info] [%s:%d] ", file, line), fmt.Sprintf(format, v...)}, "")
-	case LEVEL_WARN:
-		return strings.Join([]string{now.Format(layout), fmt.Sprintf(" [ Warn] [%s:%d] ", file, line), fmt.Sprintf(format, v...)}, "")
-	case LEVEL_ERROR:
-		return strings.Join([]string{now.Format(layout), fmt.Sprintf(" [Error] [%s:%d] ", file, line), fmt.Sprintf(format, v...)}, "")
-	case LEVEL_PANIC:
-		return strings.Join([]string{now.Format(layout), fmt.Sprintf(" [Panic] [%s:%d] ", file, line), fmt.Sprintf(format, v...)}, "")
-	case LEVEL_FATAL:
-		return strings.Join([]string{now.Format(layout), fmt.Sprintf(" [Fatal] [%s:%d] ", file, line), fmt.Sprintf(format, v...)}, "")
-	default:
+	log := &Log{
+		Now:   time.Now(),
+		PC:    callerPC(depth),
+		Level: lvl,
+		Value: fmt.Sprintf(format, v...),
 	}
-	return ""
+	log.Caller(false)
+	return formatText(log, layout)
 }
 
 func NewLogger() *Logger {
 	logger := &Logger{
-		Level:    DefaultLogLevel,
 		depth:    DefaultLogDepth,
 		Writer:   DefaultLogWriter,
 		Layout:   DefaultLogTimeLayout,
 		FullPath: BuildDir != "",
+		Color:    DefaultLogColor,
 		//filepaths: append([]string{}, filepaths...),
 	}
-	logger.Formater = logger.defaultLogFormater
+	logger.level.Store(int32(DefaultLogLevel))
+	logger.colored = colorEnabled(logger.Color, logger.Writer)
 	return logger
 }
@@ -1,10 +1,15 @@
 package log
 
 import (
+	"bytes"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestLog1(t *testing.T) {
@@ -60,3 +65,141 @@ func TestLog2(t *testing.T) {
 		Error(fmt.Sprintf("log %d", i))
 	}
 }
+
+func TestNamed(t *testing.T) {
+	buf := &bytes.Buffer{}
+	lw := &testLogWriter{}
+	logger := NewLogger()
+	logger.SetOutput(buf)
+	logger.SetStructOutput(lw)
+
+	db := logger.Named("db").With("conn", 1).Named("pool")
+	if db.Name() != "db.pool" || db.root() != logger {
+		t.Fatalf("unexpected child: name=%q", db.Name())
+	}
+	db.Info("opened")
+
+	if !strings.Contains(buf.String(), " [ Info] [db.pool] [log_test.go:") || !strings.HasSuffix(buf.String(), "opened conn=1\n") {
+		t.Fatalf("unexpected output: %q", buf.String())
+	}
+	if len(lw.logs) != 1 || lw.logs[0].Name != "db.pool" {
+		t.Fatalf("unexpected struct logs: %+v", lw.logs)
+	}
+}
+
+func TestFormaterDepth(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := NewLogger()
+	logger.SetOutput(buf)
+	// 直接赋值的 Formater 生效，且可按 log.Depth 取得调用位置
+	logger.Formater = func(log *Log) string {
+		_, file, line, _ := runtime.Caller(log.Depth)
+		return fmt.Sprintf("%s:%d %s", file[strings.LastIndex(file, "/")+1:], line, log.Value)
+	}
+
+	logger.Info("a")
+	want1 := prevLine()
+	logger.Named("n").WarnKV("b")
+	want2 := prevLine()
+
+	want := fmt.Sprintf("%s a\n%s b\n", strings.Trim(want1, "[]"), strings.Trim(want2, "[]"))
+	if buf.String() != want {
+		t.Fatalf("got %q, want %q", buf.String(), want)
+	}
+
+	// 其它 logger 的 Formater 按其自身设置格式化，SetFormater(nil) 恢复默认格式
+	other := NewLogger()
+	other.SetFormater(NewJSONFormater().Format)
+	buf.Reset()
+	logger.Formater = other.Formater
+	logger.Info("c")
+	logger.SetFormater(nil)
+	logger.Info("d")
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[0], `"msg":"c"}`) || !strings.HasSuffix(lines[1], "] d") {
+		t.Fatalf("unexpected output: %q", buf.String())
+	}
+}
+
+func TestMultiWriterSync(t *testing.T) {
+	dir := t.TempDir() + "/"
+	fileWriter := &FileWriter{
+		RootDir:     dir,
+		FileFormat:  "20060102.log",
+		EnableBufio: true,
+	}
+	defer fileWriter.Close()
+
+	buf := &bytes.Buffer{}
+	logger := NewLogger()
+	logger.SetOutput(MultiWriter(buf, fileWriter))
+	logger.Error("flush me")
+	if err := logger.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(dir + time.Now().Format(fileWriter.FileFormat))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != buf.String() || !strings.HasSuffix(string(data), "flush me\n") {
+		t.Fatalf("unexpected content: %q, %q", data, buf.String())
+	}
+}
+
+func TestSyncPipe(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	// 管道、终端不支持 Sync，不作为错误返回
+	logger := NewLogger()
+	logger.SetOutput(w)
+	if err := logger.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewLogger().Sync(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFatalFlush(t *testing.T) {
+	dir := t.TempDir() + "/"
+	fileWriter := &FileWriter{
+		RootDir:     dir,
+		FileFormat:  "20060102.log",
+		EnableBufio: true,
+	}
+	defer fileWriter.Close()
+
+	exitCode := 0
+	logger := NewLogger()
+	logger.SetOutput(nil)
+	logger.SetStructOutput(fileWriter)
+	logger.ExitFunc = func(code int) { exitCode = code }
+
+	logger.Fatal("bye")
+	if exitCode != -1 {
+		t.Fatalf("ExitFunc not called, code = %d", exitCode)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatalf("Panic should panic")
+			}
+		}()
+		logger.Panic("oops")
+	}()
+
+	data, err := ioutil.ReadFile(dir + time.Now().Format(fileWriter.FileFormat))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "[Fatal]") || !strings.Contains(string(data), "[Panic]") {
+		t.Fatalf("buffered logs should be flushed: %q", data)
+	}
+}
@@ -3,8 +3,9 @@ package log
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
-	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -22,125 +23,132 @@ type FileWriter struct {
 	SaveEach     bool
 	EnableBufio  bool
 
+	// 日志保留策略，每次切割文件后由后台协程执行，0 表示不限制
+	MaxFiles     int           // RootDir 下日志文件数量上限
+	MaxAge       time.Duration // 日志文件最长保留时间，按修改时间计算
+	MaxTotalSize int64         // RootDir 下日志文件总大小上限
+
+	// 切割后在后台压缩旧文件，Compressor 为 nil 时不压缩
+	Compressor       Compressor
+	CompressDelay    time.Duration // 切割后延迟多久开始压缩
+	CompressParallel int           // 同时压缩的文件数量，默认 1
+
 	inited       bool
 	currdir      string
+	currbase     string
 	currfile     string
 	currFileSize int
 	currFileIdx  int
 
-	logfile    *os.File
-	filewriter *bufio.Writer
-	logticker  *time.Ticker
-	inittime   time.Duration
-
-	Formater func(log *Log) string
+	logfile     *os.File
+	filewriter  *bufio.Writer
+	logticker   *time.Ticker
+	tickerDone  chan struct{}
+	janitor     chan struct{}
+	compressSem chan struct{}
+	// compressWG 为后台进行中的压缩，compressWake 关闭时跳过 CompressDelay 立即压缩
+	compressWG   sync.WaitGroup
+	compressWake chan struct{}
+	closed       bool
+	inittime     time.Duration
+
+	Formater       func(log *Log) string
+	AppendFormater func(buf []byte, log *Log) []byte
+
+	// ErrorHandler 接收创建目录、打开文件、写入等失败的 *FileError，未设置时输出到 stderr，
+	// 调用时持有 FileWriter 的锁，不能在其中再写入该 FileWriter
+	ErrorHandler func(err error)
+	// Fallback 在日志文件不可用时接收日志，默认 stderr
+	Fallback io.Writer
+	retryAt  time.Time
 }
 
 func (w *FileWriter) Write(p []byte) (n int, err error) {
 	w.Lock()
 	defer w.Unlock()
-	w.checkFileWithData(p)
-	if w.EnableBufio {
-		n, err = w.filewriter.Write(p)
-	} else {
-		n, err = w.logfile.Write(p)
-	}
-	w.currFileSize += n
-	if err != nil {
-		fmt.Printf("logfile Write failed: %v\n", err)
-	}
-	if w.SaveEach {
-		w.save()
+	if p == nil {
+		p = []byte{}
 	}
-	return n, err
+	return w.write(w.checkFileWithData(p), p, "")
 }
 
 func (w *FileWriter) WriteLog(log *Log) (n int, err error) {
 	w.Lock()
 	defer w.Unlock()
 
-	value := log.Value
-
-	if w.Formater != nil {
-		value = w.Formater(log)
-	} else {
-		_, file, line, ok := runtime.Caller(log.Depth + 1)
-		if !ok {
-			file = "???"
-			line = -1
-		} else {
-			if log.Logger.FullPath {
-				for _, v := range filepaths {
-					tmp := strings.Replace(file, v, "", 1)
-					if tmp != file {
-						file = tmp
-						break
-					}
-				}
-			} else {
-				pos := strings.LastIndex(file, "/")
-				if pos >= 0 {
-					file = file[pos+1:]
-				}
-			}
-		}
-
-		switch log.Level {
-		case LEVEL_PRINT:
-
-		case LEVEL_DEBUG:
-			value = strings.Join([]string{log.Now.Format(log.Logger.Layout), fmt.Sprintf(" [Debug] [%s:%d] ", file, line), log.Value, "\n"}, "")
-		case LEVEL_INFO:
-			value = strings.Join([]string{log.Now.Format(log.Logger.Layout), fmt.Sprintf(" [ Info] [%s:%d] ", file, line), log.Value, "\n"}, "")
-		case LEVEL_WARN:
-			value = strings.Join([]string{log.Now.Format(log.Logger.Layout), fmt.Sprintf(" [ Warn] [%s:%d] ", file, line), log.Value, "\n"}, "")
-		case LEVEL_ERROR:
-			value = strings.Join([]string{log.Now.Format(log.Logger.Layout), fmt.Sprintf(" [Error] [%s:%d] ", file, line), log.Value, "\n"}, "")
-		case LEVEL_PANIC:
-			value = strings.Join([]string{log.Now.Format(log.Logger.Layout), fmt.Sprintf(" [Panic] [%s:%d] ", file, line), log.Value, "\n"}, "")
-		case LEVEL_FATAL:
-			value = strings.Join([]string{log.Now.Format(log.Logger.Layout), fmt.Sprintf(" [Fatal] [%s:%d] ", file, line), log.Value, "\n"}, "")
-		default:
+	buf := getBuffer()
+	defer putBuffer(buf)
+	switch {
+	case w.AppendFormater != nil:
+		*buf = w.AppendFormater(*buf, log)
+	case w.Formater != nil:
+		*buf = append(*buf, w.Formater(log)...)
+	case log.Level != LEVEL_PRINT:
+		// 未设置格式化函数时按 Logger 的 Layout、FullPath 输出默认格式
+		layout := DefaultLogTimeLayout
+		if log.Logger != nil {
+			layout = log.Logger.Layout
 		}
+		log.Caller(log.Logger != nil && log.Logger.FullPath)
+		*buf = appendText(*buf, log, layout)
+	default:
+		*buf = append(*buf, log.Value...)
 	}
-
-	w.checkFileWithLog(log, len(value))
-	if w.EnableBufio {
-		n, err = w.filewriter.WriteString(value)
-	} else {
-		n, err = w.logfile.WriteString(value)
-	}
-	w.currFileSize += n
-	if err != nil {
-		fmt.Printf("logfile Write failed: %v\n", err)
-	}
-	if w.SaveEach {
-		w.save()
+	if b := *buf; log.Level != LEVEL_PRINT && len(b) > 0 && b[len(b)-1] != '\n' {
+		*buf = append(b, '\n')
 	}
-	return n, err
+
+	return w.write(w.checkFileWithLog(log, len(*buf)), *buf, "")
 }
 
 func (w *FileWriter) WriteString(str string) (n int, err error) {
 	w.Lock()
 	defer w.Unlock()
-	w.checkFileWithString(str)
-	if w.EnableBufio {
-		n, err = w.filewriter.WriteString(str)
-	} else {
-		n, err = w.logfile.WriteString(str)
+	return w.write(w.checkFileWithString(str), nil, str)
+}
+
+// write 写入 p 或 s(p 为 nil 时)，文件不可用时写入 Fallback
+func (w *FileWriter) write(cerr error, p []byte, s string) (n int, err error) {
+	if w.logfile == nil {
+		n, _ = w.fallback(p, s)
+		if cerr == nil {
+			cerr = &FileError{Kind: ErrOpenFile, Path: w.currfile, Err: os.ErrInvalid}
+		}
+		return n, cerr
+	}
+
+	switch {
+	case w.EnableBufio && p != nil:
+		n, err = w.filewriter.Write(p)
+	case w.EnableBufio:
+		n, err = w.filewriter.WriteString(s)
+	case p != nil:
+		n, err = w.logfile.Write(p)
+	default:
+		n, err = w.logfile.WriteString(s)
 	}
 	w.currFileSize += n
 	if err != nil {
-		fmt.Printf("logfile WriteString failed: %v\n", err)
+		err = w.reportError(ErrWriteFile, w.currfile, err)
 	}
 	if w.SaveEach {
 		w.save()
 	}
+	if err == nil {
+		err = cerr
+	}
 	return n, err
 }
 
+// SetFormater 设置返回 string 的格式化函数，同时清除 AppendFormater
 func (w *FileWriter) SetFormater(f func(log *Log) string) {
 	w.Formater = f
+	w.AppendFormater = nil
+}
+
+// SetAppendFormater 设置追加到 []byte 的格式化函数，非空时优先于 Formater
+func (w *FileWriter) SetAppendFormater(f func(buf []byte, log *Log) []byte) {
+	w.AppendFormater = f
 }
 
 func (w *FileWriter) Save() {
@@ -151,266 +159,298 @@ func (w *FileWriter) Save() {
 
 func (w *FileWriter) newFile(path string) error {
 	w.logfile = nil
-	w.filewriter = nil
 	//file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0666)
 	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
-	if err == nil {
-		w.logfile = file
-		if w.EnableBufio {
-			if w.filewriter == nil {
-				w.filewriter = bufio.NewWriter(file)
-			} else {
-				w.filewriter.Reset(file)
-			}
+	if err != nil {
+		w.retryAt = time.Now().Add(DefaultFileRetryInterval)
+		return w.reportError(ErrOpenFile, path, err)
+	}
+	w.logfile = file
+	if w.EnableBufio {
+		if w.filewriter == nil {
+			w.filewriter = bufio.NewWriter(file)
+		} else {
+			w.filewriter.Reset(file)
 		}
-	} else {
-		fmt.Printf("logfile newFile failed: %s, %s\n", path, err.Error())
 	}
-	return err
+	return nil
 }
 
-func (w *FileWriter) checkFileWithData(data []byte) bool {
-	var (
-		err      error = nil
-		now      time.Time
-		filename = ""
-		currfile = ""
-	)
-
-	if w.TimePrefix != "" {
-		now, err = time.Parse(w.TimePrefix, string(data[w.TimeBegin:w.TimeBegin+len(w.TimePrefix)]))
-		if err != nil {
-			fmt.Printf("logfile time.Parse(%s) failed: %s\n", string(data[:len(w.TimePrefix)]), err.Error())
-			return false
-		}
-	} else {
-		now = time.Now()
+func (w *FileWriter) parseTime(s string) (time.Time, error) {
+	end := w.TimeBegin + len(w.TimePrefix)
+	if end > len(s) {
+		return time.Time{}, w.reportError(ErrParseTime, "", fmt.Errorf("data too short: %q", s))
 	}
-
-	filename = now.Format(w.FileFormat)
-
-	if !w.inited {
-		w.Init(now)
+	now, err := time.Parse(w.TimePrefix, s[w.TimeBegin:end])
+	if err != nil {
+		return now, w.reportError(ErrParseTime, "", err)
 	}
+	return now, nil
+}
 
-	currdir := w.RootDir
-	if w.DirFormat != "" {
-		currdir += now.Format(w.DirFormat)
-	}
-	if w.currdir != currdir {
-		w.currdir = currdir
-		err = w.makeDir(currdir)
+func (w *FileWriter) checkFileWithData(data []byte) error {
+	now := time.Now()
+	if w.TimePrefix != "" {
+		var err error
+		end := w.TimeBegin + len(w.TimePrefix)
+		if end > len(data) {
+			end = len(data)
+		}
+		if now, err = w.parseTime(string(data[:end])); err != nil {
+			return err
+		}
 	}
+	return w.checkFile(now, len(data))
+}
 
-	if w.currFileIdx == 0 {
-		currfile = currdir + filename
-	} else {
-		currfile = fmt.Sprintf("%s%s.%04d", currdir, filename, w.currFileIdx)
+func (w *FileWriter) checkFileWithLog(log *Log, size int) error {
+	now := log.Now
+	if now.IsZero() {
+		now = time.Now()
 	}
+	return w.checkFile(now, size)
+}
 
-	if w.currfile != currfile {
-		w.currFileIdx = 0
-		w.currFileSize = 0
-		w.currfile = currfile
-
-		// w.save()
-		if w.logfile != nil {
-			w.logfile.Close()
-		}
-
-		err = w.newFile(w.currfile)
-	} else if w.MaxFileSize > 0 && w.currFileSize+len(data) > w.MaxFileSize {
-		w.currFileIdx++
-		w.currFileSize = 0
-		w.currfile = fmt.Sprintf("%s%s.%04d", currdir, filename, w.currFileIdx)
-
-		// w.save()
-		if w.logfile != nil {
-			w.logfile.Close()
+func (w *FileWriter) checkFileWithString(str string) error {
+	now := time.Now()
+	if w.TimePrefix != "" {
+		var err error
+		if now, err = w.parseTime(str); err != nil {
+			return err
 		}
-
-		err = w.newFile(w.currfile)
 	}
-
-	return err == nil
+	return w.checkFile(now, len(str))
 }
 
-func (w *FileWriter) checkFileWithLog(log *Log, size int) bool {
+func (w *FileWriter) checkFile(now time.Time, size int) error {
 	var (
 		err      error = nil
-		now            = log.Now
-		filename       = ""
-		currfile       = ""
+		filename       = now.Format(w.FileFormat)
 	)
 
-	if now.IsZero() {
-		now = time.Now()
-	}
-
-	filename = now.Format(w.FileFormat)
-
 	if !w.inited {
 		w.Init(now)
 	}
 
 	currdir := w.RootDir
 	if w.DirFormat != "" {
-		currdir += now.Format(w.DirFormat)
+		currdir += now.Format(w.DirFormat) //path.Join(w.RootDir, now.Format(w.DirFormat)) //
 	}
 	if w.currdir != currdir {
+		// 上次创建目录失败，重试间隔内不再重复创建和报告错误
+		if time.Now().Before(w.retryAt) {
+			return nil
+		}
+		if err = w.makeDir(currdir); err != nil {
+			return err
+		}
 		w.currdir = currdir
-		err = w.makeDir(currdir)
-	}
-
-	if w.currFileIdx == 0 {
-		currfile = currdir + filename
-	} else {
-		currfile = fmt.Sprintf("%s%s.%04d", currdir, filename, w.currFileIdx)
 	}
 
-	if w.currfile != currfile {
-		w.currFileIdx = 0
-		w.currFileSize = 0
-		w.currfile = currfile
-
-		// w.save()
-		if w.logfile != nil {
-			w.logfile.Close()
-		}
-
-		err = w.newFile(w.currfile)
-	} else if w.MaxFileSize > 0 && w.currFileSize+size > w.MaxFileSize {
+	if base := currdir + filename; w.currbase != base {
+		// 新的周期(含进程重启后首次写入)，从磁盘上已有文件恢复切割序号和大小
+		w.currbase = base
+		w.currFileIdx, w.currFileSize = w.resumeFile(currdir, filename)
+		w.currfile = indexFileName(base, w.currFileIdx)
+		err = w.rotate()
+	} else if w.MaxFileSize > 0 && w.currFileSize > 0 && w.currFileSize+size > w.MaxFileSize {
 		w.currFileIdx++
 		w.currFileSize = 0
-		w.currfile = fmt.Sprintf("%s%s.%04d", currdir, filename, w.currFileIdx)
-
-		// w.save()
-		if w.logfile != nil {
-			w.logfile.Close()
-		}
-
-		err = w.newFile(w.currfile)
+		w.currfile = indexFileName(base, w.currFileIdx)
+		err = w.rotate()
+	} else if w.logfile == nil && !time.Now().Before(w.retryAt) {
+		// 上次创建文件失败，重试
+		err = w.rotate()
 	}
 
-	return err == nil
+	return err
 }
 
-func (w *FileWriter) checkFileWithString(str string) bool {
-	var (
-		err      error = nil
-		now      time.Time
-		filename = ""
-		currfile = ""
-	)
-
-	if w.TimePrefix != "" {
-		now, err = time.Parse(w.TimePrefix, str[w.TimeBegin:w.TimeBegin+len(w.TimePrefix)])
-		if err != nil {
-			fmt.Printf("logfile time.Parse(%s) failed: %s\n", str[:len(w.TimePrefix)], err.Error())
-			return false
-		}
-	} else {
-		now = time.Now()
-	}
-
-	filename = now.Format(w.FileFormat)
-
-	if !w.inited {
-		w.Init(now)
+func indexFileName(base string, idx int) string {
+	if idx == 0 {
+		return base
 	}
+	return fmt.Sprintf("%s.%04d", base, idx)
+}
 
-	currdir := w.RootDir
-	if w.DirFormat != "" {
-		currdir += now.Format(w.DirFormat) //path.Join(w.RootDir, now.Format(w.DirFormat)) //
-	}
-	if w.currdir != currdir {
-		w.currdir = currdir
-		err = w.makeDir(currdir)
+// resumeFile 扫描 dir 下 filename 及 filename.0001 等已有文件，返回最大序号及其大小，
+// 最大序号的文件已被压缩时返回下一个序号
+func (w *FileWriter) resumeFile(dir, filename string) (idx int, size int) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, 0
+	}
+	found, compressed := false, false
+	for _, entry := range entries {
+		name := trimCompressExt(entry.Name())
+		i := -1
+		if name == filename {
+			i = 0
+		} else if strings.HasPrefix(name, filename+".") {
+			if n, err := strconv.Atoi(name[len(filename)+1:]); err == nil && n > 0 {
+				i = n
+			}
+		}
+		if i < 0 || (found && i < idx) {
+			continue
+		}
+		if found && i == idx && !compressed {
+			continue
+		}
+		found, idx, compressed = true, i, name != entry.Name()
+		size = 0
+		if !compressed {
+			if info, err := entry.Info(); err == nil {
+				size = int(info.Size())
+			}
+		}
 	}
-
-	if w.currFileIdx == 0 {
-		currfile = currdir + filename
-	} else {
-		currfile = fmt.Sprintf("%s%s.%04d", currdir, filename, w.currFileIdx)
+	if compressed {
+		return idx + 1, 0
 	}
+	return idx, size
+}
 
-	if w.currfile != currfile {
-		w.currFileIdx = 0
-		w.currFileSize = 0
-		w.currfile = currfile
-
-		// w.save()
-		if w.logfile != nil {
-			w.logfile.Close()
+// rotate 关闭当前文件并打开 w.currfile
+func (w *FileWriter) rotate() error {
+	// w.save()
+	if w.logfile != nil {
+		if w.filewriter != nil {
+			w.filewriter.Flush()
 		}
-
-		err = w.newFile(w.currfile)
-	} else if w.MaxFileSize > 0 && w.currFileSize+len(str) > w.MaxFileSize {
-		w.currFileIdx++
-		w.currFileSize = 0
-		w.currfile = fmt.Sprintf("%s%s.%04d", currdir, filename, w.currFileIdx)
-
-		// w.save()
-		if w.logfile != nil {
-			w.logfile.Close()
+		prev := w.logfile.Name()
+		w.logfile.Close()
+		if w.Compressor != nil && prev != w.currfile {
+			w.compressLater(prev)
 		}
-
-		err = w.newFile(w.currfile)
 	}
 
-	return err == nil
+	err := w.newFile(w.currfile)
+	w.startJanitor()
+	return err
 }
 
 func (w *FileWriter) makeDir(path string) error {
-	err := os.MkdirAll(path, 0777)
-	if err != nil {
-		fmt.Printf("logfile makeDir failed: %s, %s\n", path, err.Error())
+	if err := os.MkdirAll(path, 0777); err != nil {
+		w.retryAt = time.Now().Add(DefaultFileRetryInterval)
+		return w.reportError(ErrMakeDir, path, err)
 	}
-	return err
+	return nil
 }
 
 func (w *FileWriter) Init(now time.Time) {
 	if !w.inited {
 		w.inited = true
+		w.closed = false
 		currdir := w.RootDir
 		if w.DirFormat != "" {
 			currdir += now.Format(w.DirFormat)
 		}
-		if err := w.makeDir(currdir); err != nil {
-			fmt.Printf("logfile init mkdir(%s) failed: %v\n", w.RootDir, err)
+		if w.makeDir(currdir) == nil {
+			w.currdir = currdir
 		}
 
 		if !w.SaveEach { // && w.EnableBufio {
-			go func() {
+			if w.SyncInterval <= 0 {
+				w.SyncInterval = time.Second * 5
+			}
+			w.logticker = time.NewTicker(w.SyncInterval)
+			w.tickerDone = make(chan struct{})
+			go func(ticker *time.Ticker, done chan struct{}) {
 				defer func() {
 					recover()
 				}()
-				if w.SyncInterval <= 0 {
-					w.SyncInterval = time.Second * 5
-				}
-				w.logticker = time.NewTicker(w.SyncInterval)
 				for {
-					_, ok := <-w.logticker.C
-					w.Save()
-					if !ok {
+					select {
+					case <-ticker.C:
+						w.Save()
+					case <-done:
 						return
 					}
 				}
-			}()
+			}(w.logticker, w.tickerDone)
+		}
+	}
+}
+
+// Sync 将 bufio 缓冲写入文件并落盘
+func (w *FileWriter) Sync() error {
+	w.Lock()
+	defer w.Unlock()
+	if err := w.sync(); err != nil {
+		return w.reportError(ErrSyncFile, w.currfile, err)
+	}
+	return nil
+}
+
+// Close 落盘并关闭当前文件，等待后台压缩完成，之后再写入时重新打开文件
+func (w *FileWriter) Close() error {
+	err := w.close()
+	// 压缩协程完成时需要加锁，须在释放锁后等待
+	w.compressWG.Wait()
+	return err
+}
+
+func (w *FileWriter) close() error {
+	w.Lock()
+	defer w.Unlock()
+
+	w.closed = true
+	if w.compressWake != nil {
+		close(w.compressWake)
+		w.compressWake = nil
+	}
+	if w.logticker != nil {
+		w.logticker.Stop()
+		close(w.tickerDone)
+		w.logticker = nil
+		w.tickerDone = nil
+	}
+	w.stopJanitor()
+
+	err := w.sync()
+	if w.logfile != nil {
+		if cerr := w.logfile.Close(); err == nil {
+			err = cerr
 		}
 	}
+	w.logfile = nil
+	w.filewriter = nil
+	w.inited = false
+	w.currdir = ""
+	w.currbase = ""
+	w.currfile = ""
+	w.currFileSize = 0
+	w.currFileIdx = 0
+	return err
+}
+
+func (w *FileWriter) sync() error {
+	if w.filewriter != nil && w.logfile != nil {
+		if err := w.filewriter.Flush(); err != nil {
+			return err
+		}
+	}
+	if w.logfile != nil {
+		return w.logfile.Sync()
+	}
+	return nil
 }
 
 func (w *FileWriter) save() {
+	var err error
 	if w.EnableBufio {
-		if w.filewriter != nil {
-			w.filewriter.Flush()
+		if w.filewriter != nil && w.logfile != nil {
+			err = w.filewriter.Flush()
 		}
 	} else {
 		if w.logfile != nil {
-			w.logfile.Sync()
+			err = w.logfile.Sync()
 		}
 	}
+	if err != nil {
+		w.reportError(ErrSyncFile, w.currfile, err)
+	}
 }
 
 // func NewLogFile() *FileWriter {
@@ -0,0 +1,142 @@
+package log
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Compressor 用于压缩切割后的日志文件，可实现该接口接入 zstd 等算法
+type Compressor interface {
+	// Ext 为压缩文件的扩展名，如 ".gz"
+	Ext() string
+	Compress(dst io.Writer, src io.Reader) error
+}
+
+type GzipCompressor struct {
+	Level int // gzip 压缩级别，0 使用 gzip.DefaultCompression
+}
+
+func (c GzipCompressor) Ext() string {
+	return ".gz"
+}
+
+func (c GzipCompressor) Compress(dst io.Writer, src io.Reader) error {
+	level := c.Level
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+	zw, err := gzip.NewWriterLevel(dst, level)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(zw, src); err != nil {
+		zw.Close()
+		return err
+	}
+	return zw.Close()
+}
+
+var (
+	compressorsMu sync.RWMutex
+	compressors   = map[string]Compressor{".gz": GzipCompressor{}}
+)
+
+// RegisterCompressor 注册压缩算法，用于日志文件列举和保留策略识别压缩后的文件名
+func RegisterCompressor(c Compressor) {
+	compressorsMu.Lock()
+	defer compressorsMu.Unlock()
+	compressors[c.Ext()] = c
+}
+
+// trimCompressExt 去掉已注册的压缩扩展名
+func trimCompressExt(name string) string {
+	compressorsMu.RLock()
+	defer compressorsMu.RUnlock()
+	for ext := range compressors {
+		if strings.HasSuffix(name, ext) {
+			return name[:len(name)-len(ext)]
+		}
+	}
+	return name
+}
+
+// compressLater 在后台压缩切割后的文件，须在持有锁时调用
+func (w *FileWriter) compressLater(path string) {
+	if w.compressSem == nil {
+		n := w.CompressParallel
+		if n <= 0 {
+			n = 1
+		}
+		w.compressSem = make(chan struct{}, n)
+	}
+	if w.compressWake == nil {
+		w.compressWake = make(chan struct{})
+	}
+	compressor, delay, sem, wake := w.Compressor, w.CompressDelay, w.compressSem, w.compressWake
+	w.compressWG.Add(1)
+	go func() {
+		defer w.compressWG.Done()
+		if delay > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-wake:
+				timer.Stop()
+			}
+		}
+		sem <- struct{}{}
+		defer func() { <-sem }()
+		err := compressFile(compressor, path)
+		w.Lock()
+		if err != nil {
+			w.reportError(ErrCompress, path, err)
+		}
+		// Close 后不再启动清理协程
+		if !w.closed {
+			w.startJanitor()
+		}
+		w.Unlock()
+	}()
+}
+
+// compressFile 将 path 压缩为 path+Ext，压缩文件完整写入并落盘后才删除原文件
+func compressFile(c Compressor, path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	dstPath := path + c.Ext()
+	tmpPath := dstPath + ".tmp"
+	dst, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0666)
+	if err != nil {
+		return err
+	}
+	if err = c.Compress(dst, src); err == nil {
+		err = dst.Sync()
+	}
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, dstPath)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	// 保留原文件的修改时间，保证 MaxAge 按原文件计算
+	os.Chtimes(dstPath, info.ModTime(), info.ModTime())
+	src.Close()
+	return os.Remove(path)
+}
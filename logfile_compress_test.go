@@ -0,0 +1,84 @@
+package log
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestFileWriterCompress(t *testing.T) {
+	dir := t.TempDir() + "/"
+	w := &FileWriter{
+		RootDir:     dir,
+		FileFormat:  "20060102.log",
+		MaxFileSize: 10,
+		SaveEach:    true,
+		Compressor:  GzipCompressor{},
+	}
+	defer w.Close()
+	w.WriteString("aaaaaaaa\n")
+	w.WriteString("bbbbbbbb\n")
+
+	base := dir + time.Now().Format(w.FileFormat)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(base); os.IsNotExist(err) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s was not compressed", base)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	f, err := os.Open(base + ".gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(zr)
+	if err != nil || string(data) != "aaaaaaaa\n" {
+		t.Fatalf("unexpected content %q, %v", data, err)
+	}
+	if !w.isLogFile("20200101.log.0001.gz") || w.isLogFile("20200101.log.gz.tmp") {
+		t.Fatalf("isLogFile should understand compressed names")
+	}
+}
+
+func TestFileWriterCloseWaitsCompress(t *testing.T) {
+	dir := t.TempDir() + "/"
+	w := &FileWriter{
+		RootDir:       dir,
+		FileFormat:    "20060102.log",
+		MaxFileSize:   10,
+		MaxFiles:      10,
+		Compressor:    GzipCompressor{},
+		CompressDelay: time.Hour,
+	}
+	w.WriteString("aaaaaaaa\n")
+	w.WriteString("bbbbbbbb\n")
+
+	begin := time.Now()
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(begin) > 5*time.Second {
+		t.Fatalf("Close should not wait for CompressDelay")
+	}
+	base := dir + time.Now().Format(w.FileFormat)
+	if _, err := os.Stat(base + ".gz"); err != nil {
+		t.Fatalf("compression should finish before Close returns: %v", err)
+	}
+	w.Lock()
+	janitor := w.janitor
+	w.Unlock()
+	if janitor != nil {
+		t.Fatalf("janitor should not be started after Close")
+	}
+}
@@ -0,0 +1,62 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// FileWriter 各类失败对应的错误，可通过 errors.Is(err, ErrOpenFile) 判断
+var (
+	ErrMakeDir   = errors.New("logfile makeDir failed")
+	ErrOpenFile  = errors.New("logfile newFile failed")
+	ErrParseTime = errors.New("logfile time.Parse failed")
+	ErrWriteFile = errors.New("logfile Write failed")
+	ErrSyncFile  = errors.New("logfile Sync failed")
+	ErrCompress  = errors.New("logfile compress failed")
+	ErrCleanup   = errors.New("logfile Cleanup failed")
+
+	DefaultFileRetryInterval = time.Second
+)
+
+type FileError struct {
+	Kind error  // ErrMakeDir、ErrOpenFile 等
+	Path string // 相关的文件或目录
+	Err  error  // 底层错误
+}
+
+func (e *FileError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("%v: %v", e.Kind, e.Err)
+	}
+	return fmt.Sprintf("%v: %s, %v", e.Kind, e.Path, e.Err)
+}
+
+func (e *FileError) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// reportError 将错误交给 ErrorHandler，未设置时输出到 stderr
+func (w *FileWriter) reportError(kind error, path string, err error) error {
+	e := &FileError{Kind: kind, Path: path, Err: err}
+	if w.ErrorHandler != nil {
+		w.ErrorHandler(e)
+	} else {
+		fmt.Fprintln(os.Stderr, e)
+	}
+	return e
+}
+
+// fallback 在日志文件不可用时将数据写入 Fallback，默认 stderr
+func (w *FileWriter) fallback(p []byte, s string) (int, error) {
+	var out io.Writer = os.Stderr
+	if w.Fallback != nil {
+		out = w.Fallback
+	}
+	if p != nil {
+		return out.Write(p)
+	}
+	return io.WriteString(out, s)
+}
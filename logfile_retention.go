@@ -0,0 +1,182 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type logFileInfo struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// isLogFile 判断文件名是否为 FileFormat 生成的日志文件，包括按 size 切割的 name.0001 形式及压缩后的 name.0001.gz 形式
+func (w *FileWriter) isLogFile(name string) bool {
+	name = trimCompressExt(name)
+	if _, err := time.Parse(w.FileFormat, name); err == nil {
+		return true
+	}
+	pos := strings.LastIndex(name, ".")
+	if pos < 0 || !isIndexSuffix(name[pos+1:]) {
+		return false
+	}
+	_, err := time.Parse(w.FileFormat, name[:pos])
+	return err == nil
+}
+
+// isIndexSuffix 判断 s 是否为 indexFileName 生成的序号，即 %04d 格式的正整数
+func isIndexSuffix(s string) bool {
+	idx, err := strconv.Atoi(s)
+	return err == nil && idx > 0 && fmt.Sprintf("%04d", idx) == s
+}
+
+// listLogFiles 列出 RootDir(含 DirFormat 子目录)下的所有日志文件，按修改时间从旧到新排序
+func (w *FileWriter) listLogFiles() ([]logFileInfo, error) {
+	var files []logFileInfo
+	err := filepath.Walk(w.RootDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.Mode().IsRegular() && w.isLogFile(info.Name()) {
+			files = append(files, logFileInfo{path: path, size: info.Size(), modTime: info.ModTime()})
+		}
+		return nil
+	})
+	sort.SliceStable(files, func(i, j int) bool {
+		if files[i].modTime.Equal(files[j].modTime) {
+			return files[i].path < files[j].path
+		}
+		return files[i].modTime.Before(files[j].modTime)
+	})
+	return files, err
+}
+
+func (w *FileWriter) retentionEnabled() bool {
+	return w.MaxFiles > 0 || w.MaxAge > 0 || w.MaxTotalSize > 0
+}
+
+// startJanitor 通知后台协程按保留策略清理旧日志，须在持有锁时调用
+func (w *FileWriter) startJanitor() {
+	if !w.retentionEnabled() {
+		return
+	}
+	if w.janitor == nil {
+		w.janitor = make(chan struct{}, 1)
+		go func(ch chan struct{}) {
+			for range ch {
+				if err := w.Cleanup(); err != nil {
+					w.Lock()
+					w.reportError(ErrCleanup, w.RootDir, err)
+					w.Unlock()
+				}
+			}
+		}(w.janitor)
+	}
+	select {
+	case w.janitor <- struct{}{}:
+	default:
+	}
+}
+
+func (w *FileWriter) stopJanitor() {
+	if w.janitor != nil {
+		close(w.janitor)
+		w.janitor = nil
+	}
+}
+
+// Cleanup 按 MaxFiles、MaxAge、MaxTotalSize 删除旧日志文件及清理后为空的子目录，当前正在写的文件不会被删除
+func (w *FileWriter) Cleanup() error {
+	w.Lock()
+	currfile := filepath.Clean(w.currfile)
+	maxFiles, maxAge, maxTotalSize := w.MaxFiles, w.MaxAge, w.MaxTotalSize
+	w.Unlock()
+
+	files, err := w.listLogFiles()
+	if err != nil {
+		return err
+	}
+
+	var (
+		errs      []error
+		now       = time.Now()
+		count     = len(files)
+		totalSize int64
+		remains   = files[:0]
+	)
+	for _, f := range files {
+		totalSize += f.size
+	}
+	remove := func(f logFileInfo) {
+		if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err)
+			return
+		}
+		count--
+		totalSize -= f.size
+	}
+
+	for _, f := range files {
+		if f.path == currfile {
+			remains = append(remains, f)
+			continue
+		}
+		if maxAge > 0 && now.Sub(f.modTime) > maxAge {
+			remove(f)
+		} else {
+			remains = append(remains, f)
+		}
+	}
+	for _, f := range remains {
+		if f.path == currfile {
+			continue
+		}
+		if (maxFiles > 0 && count > maxFiles) || (maxTotalSize > 0 && totalSize > maxTotalSize) {
+			remove(f)
+		}
+	}
+
+	if w.DirFormat != "" {
+		w.removeEmptyDirs(filepath.Dir(currfile))
+	}
+	return errors.Join(errs...)
+}
+
+// removeEmptyDirs 删除 RootDir 下按 DirFormat 生成的空子目录，keep 及其上级目录除外
+func (w *FileWriter) removeEmptyDirs(keep string) {
+	var (
+		root   = filepath.Clean(w.RootDir)
+		layout = strings.TrimSuffix(filepath.ToSlash(w.DirFormat), "/")
+		dirs   []string
+	)
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() || path == root {
+			return nil
+		}
+		if rel, err := filepath.Rel(root, path); err == nil {
+			if _, err := time.Parse(layout, filepath.ToSlash(rel)); err == nil {
+				dirs = append(dirs, path)
+			}
+		}
+		return nil
+	})
+	// 先删除深层目录
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if dirs[i] == keep || strings.HasPrefix(keep, dirs[i]+string(filepath.Separator)) {
+			continue
+		}
+		if entries, err := os.ReadDir(dirs[i]); err == nil && len(entries) == 0 {
+			os.Remove(dirs[i])
+		}
+	}
+}
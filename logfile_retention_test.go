@@ -0,0 +1,97 @@
+package log
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestFileWriterCleanup(t *testing.T) {
+	dir := t.TempDir() + "/"
+	now := time.Now()
+	old := []struct {
+		path string
+		age  time.Duration
+	}{
+		{"20200101/20200101.log", 72 * time.Hour},
+		{"20200101/20200101.log.0001", 71 * time.Hour},
+		{"20200102/20200102.log", 48 * time.Hour},
+		{"20200103/20200103.log", 24 * time.Hour},
+	}
+	for _, f := range old {
+		os.MkdirAll(dir+f.path[:9], 0777)
+		ioutil.WriteFile(dir+f.path, make([]byte, 100), 0666)
+		os.Chtimes(dir+f.path, now.Add(-f.age), now.Add(-f.age))
+	}
+	ioutil.WriteFile(dir+"20200101/readme.txt", nil, 0666)
+	os.MkdirAll(dir+"unrelated/empty", 0777)
+
+	w := &FileWriter{
+		RootDir:    dir,
+		DirFormat:  "20060102/",
+		FileFormat: "20060102.log",
+		SaveEach:   true,
+		MaxFiles:   2,
+		MaxAge:     60 * time.Hour,
+	}
+	defer w.Close()
+	w.WriteString("current\n")
+	if err := w.Cleanup(); err != nil {
+		t.Fatal(err)
+	}
+
+	exists := func(path string) bool {
+		_, err := os.Stat(dir + path)
+		return err == nil
+	}
+	// MaxAge 删除 20200101 下的两个文件，MaxFiles 再删除 20200102.log
+	for _, path := range []string{"20200101/20200101.log", "20200101/20200101.log.0001", "20200102"} {
+		if exists(path) {
+			t.Fatalf("%s should be removed", path)
+		}
+	}
+	for _, path := range []string{"20200101/readme.txt", "20200103/20200103.log", now.Format("20060102/20060102.log"), "unrelated/empty"} {
+		if !exists(path) {
+			t.Fatalf("%s should be kept", path)
+		}
+	}
+
+	w.Lock()
+	w.MaxFiles = 0
+	w.MaxAge = 0
+	w.MaxTotalSize = 50
+	w.Unlock()
+	w.Cleanup()
+	if exists("20200103") || !exists(now.Format("20060102/20060102.log")) {
+		t.Fatalf("MaxTotalSize should remove all but the current file")
+	}
+}
+
+func TestFileWriterCleanupDateSuffix(t *testing.T) {
+	dir := t.TempDir() + "/"
+	now := time.Now()
+	for i, name := range []string{"app.log.20200101", "app.log.20200102", "app.log.20200102.0001", "app.log.20200103"} {
+		ioutil.WriteFile(dir+name, nil, 0666)
+		mtime := now.Add(time.Duration(i-10) * time.Hour)
+		os.Chtimes(dir+name, mtime, mtime)
+	}
+	ioutil.WriteFile(dir+"app.log.20200103.1", nil, 0666)
+
+	w := &FileWriter{RootDir: dir, FileFormat: "app.log.20060102", MaxFiles: 1}
+	defer w.Close()
+	w.WriteString("current\n")
+	if err := w.Cleanup(); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	// 只保留当前文件及不是日志文件的 app.log.20200103.1
+	if len(names) != 2 || names[0] != "app.log.20200103.1" || names[1] != now.Format(w.FileFormat) {
+		t.Fatalf("unexpected files: %v", names)
+	}
+}
@@ -1,11 +1,15 @@
 package log
 
 import (
+	"bytes"
 	//"encoding/json"
+	"errors"
 	//"fmt"
+	"io/ioutil"
 	"math/rand"
+	"os"
 	"testing"
-	//"time"
+	"time"
 )
 
 func Benchmark_LogFileBytes128(b *testing.B) {
@@ -97,3 +101,113 @@ func Benchmark_LogFileBufioBytes512(b *testing.B) {
 		w.Write(data)
 	}
 }
+
+func TestFileWriterClose(t *testing.T) {
+	dir := t.TempDir() + "/"
+	w := &FileWriter{
+		RootDir:     dir,
+		FileFormat:  "20060102.log",
+		EnableBufio: true,
+	}
+	w.WriteString("a\n")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if w.logticker != nil {
+		t.Fatalf("ticker should be stopped")
+	}
+	w.WriteString("b\n")
+	w.Sync()
+
+	data, err := ioutil.ReadFile(dir + time.Now().Format(w.FileFormat))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "a\nb\n" {
+		t.Fatalf("unexpected content: %q", data)
+	}
+	w.Close()
+}
+
+func TestFileWriterResume(t *testing.T) {
+	dir := t.TempDir() + "/"
+	base := dir + time.Now().Format("20060102.log")
+	ioutil.WriteFile(base, []byte("0000000\n"), 0666)
+	ioutil.WriteFile(base+".0001", []byte("111\n"), 0666)
+
+	w := &FileWriter{
+		RootDir:     dir,
+		FileFormat:  "20060102.log",
+		MaxFileSize: 10,
+		SaveEach:    true,
+	}
+	w.WriteString("abcd\n")
+	w.WriteString("efgh\n")
+	w.Close()
+
+	for path, want := range map[string]string{
+		base:           "0000000\n",
+		base + ".0001": "111\nabcd\n",
+		base + ".0002": "efgh\n",
+	} {
+		data, _ := ioutil.ReadFile(path)
+		if string(data) != want {
+			t.Fatalf("%s: got %q, want %q", path, data, want)
+		}
+	}
+
+	// 最大序号的文件已压缩时从下一个序号开始
+	os.Rename(base+".0002", base+".0002.gz")
+	if idx, size := w.resumeFile(dir, time.Now().Format("20060102.log")); idx != 3 || size != 0 {
+		t.Fatalf("resumeFile = %d, %d", idx, size)
+	}
+}
+
+func TestFileWriterErrorHandler(t *testing.T) {
+	dir := t.TempDir() + "/"
+	// RootDir 下的同名文件使目录无法创建
+	ioutil.WriteFile(dir+"sub", nil, 0666)
+
+	var errs []error
+	fallback := &bytes.Buffer{}
+	w := &FileWriter{
+		RootDir:      dir + "sub/",
+		FileFormat:   "20060102.log",
+		SaveEach:     true,
+		ErrorHandler: func(err error) { errs = append(errs, err) },
+		Fallback:     fallback,
+	}
+	defer w.Close()
+
+	if _, err := w.WriteString("a\n"); !errors.Is(err, ErrMakeDir) && !errors.Is(err, ErrOpenFile) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fallback.String() != "a\n" || len(errs) == 0 || !errors.Is(errs[0], ErrMakeDir) {
+		t.Fatalf("fallback = %q, errs = %v", fallback.String(), errs)
+	}
+	// 重试间隔内不再重复报告错误
+	for i := 0; i < 100; i++ {
+		w.WriteString("a\n")
+	}
+	if len(errs) != 1 {
+		t.Fatalf("errs = %d, want 1", len(errs))
+	}
+
+	// 目录恢复后自动重新创建文件
+	os.Remove(dir + "sub")
+	w.Lock()
+	w.retryAt = time.Time{}
+	w.Unlock()
+	if _, err := w.WriteString("b\n"); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := ioutil.ReadFile(dir + "sub/" + time.Now().Format(w.FileFormat))
+	if string(data) != "b\n" {
+		t.Fatalf("unexpected content: %q", data)
+	}
+
+	w.TimePrefix = DefaultLogTimeLayout
+	if _, err := w.WriteString("x"); !errors.Is(err, ErrParseTime) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
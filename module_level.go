@@ -0,0 +1,181 @@
+package log
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type moduleRule struct {
+	pattern string
+	level   int
+}
+
+// moduleLevels 保存按 logger 名字或调用者包路径设置的级别，调用位置的匹配结果按 PC 缓存
+type moduleLevels struct {
+	spec  string
+	def   int // "*" 设置的级别，未设置时为 -1
+	rules []moduleRule
+	pcs   sync.Map // uintptr -> int，-1 表示未匹配
+	names sync.Map // string -> int，-1 表示未匹配
+}
+
+// parseModuleLevels 解析 "*=warn,myapp/db=debug,db.pool=info" 形式的配置，单独的级别等同于 "*=level"
+func parseModuleLevels(spec string) (*moduleLevels, error) {
+	m := &moduleLevels{spec: spec, def: -1}
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		pattern, name := "*", item
+		if pos := strings.LastIndex(item, "="); pos >= 0 {
+			pattern, name = strings.TrimSpace(item[:pos]), strings.TrimSpace(item[pos+1:])
+		}
+		level, err := ParseLevel(name)
+		if err != nil {
+			return nil, fmt.Errorf("log module level %q: %v", item, err)
+		}
+		if pattern == "*" || pattern == "" {
+			m.def = level
+		} else {
+			m.rules = append(m.rules, moduleRule{pattern: strings.Trim(pattern, "/"), level: level})
+		}
+	}
+	// 最长的规则优先匹配
+	sort.SliceStable(m.rules, func(i, j int) bool {
+		return len(m.rules[i].pattern) > len(m.rules[j].pattern)
+	})
+	return m, nil
+}
+
+// matchModule 判断 key(包路径或 logger 名字)是否在 pattern 表示的模块下，按 "/" 或 "." 分段匹配
+func matchModule(key, pattern string) bool {
+	for off := 0; off < len(key); {
+		pos := strings.Index(key[off:], pattern)
+		if pos < 0 {
+			return false
+		}
+		pos += off
+		end := pos + len(pattern)
+		if (pos == 0 || key[pos-1] == '/') && (end == len(key) || key[end] == '/' || key[end] == '.') {
+			return true
+		}
+		off = pos + 1
+	}
+	return false
+}
+
+// match 返回 key 匹配的最长规则，无匹配时返回 -1 和 0
+func (m *moduleLevels) match(key string) (level int, length int) {
+	for _, r := range m.rules {
+		if matchModule(key, r.pattern) {
+			return r.level, len(r.pattern)
+		}
+	}
+	return -1, 0
+}
+
+func (m *moduleLevels) nameLevel(name string) (int, int) {
+	if name == "" || len(m.rules) == 0 {
+		return -1, 0
+	}
+	if v, ok := m.names.Load(name); ok {
+		lv := v.([2]int)
+		return lv[0], lv[1]
+	}
+	level, length := m.match(name)
+	m.names.Store(name, [2]int{level, length})
+	return level, length
+}
+
+func (m *moduleLevels) pcLevel(pc uintptr) (int, int) {
+	if pc == 0 || len(m.rules) == 0 {
+		return -1, 0
+	}
+	if v, ok := m.pcs.Load(pc); ok {
+		lv := v.([2]int)
+		return lv[0], lv[1]
+	}
+	level, length := -1, 0
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		level, length = m.match(funcPackage(fn.Name()))
+	}
+	m.pcs.Store(pc, [2]int{level, length})
+	return level, length
+}
+
+// level 返回 logger 名字或调用位置匹配的级别，都未匹配时返回 "*" 的级别或 def
+func (m *moduleLevels) level(name string, pc uintptr, def int) int {
+	level, length := m.nameLevel(name)
+	if l, n := m.pcLevel(pc); n > length {
+		level = l
+	}
+	if level >= 0 {
+		return level
+	}
+	if m.def >= 0 {
+		return m.def
+	}
+	return def
+}
+
+// minLevel 返回所有规则中最低的级别，用于无法确定调用位置时的预判
+func (m *moduleLevels) minLevel(def int) int {
+	if m.def >= 0 {
+		def = m.def
+	}
+	for _, r := range m.rules {
+		if r.level < def {
+			def = r.level
+		}
+	}
+	return def
+}
+
+// funcPackage 从 "github.com/a/b.(*T).Method" 形式的函数名中取出包路径
+func funcPackage(name string) string {
+	pos := strings.LastIndex(name, "/")
+	if dot := strings.Index(name[pos+1:], "."); dot >= 0 {
+		return name[:pos+1+dot]
+	}
+	return name
+}
+
+// SetModuleLevels 按 logger 名字或调用者包路径设置级别，如 "*=warn,myapp/db=debug"，
+// 空串清除所有设置，未匹配的日志使用 "*" 的级别或 SetLevel 设置的级别
+func (logger *Logger) SetModuleLevels(spec string) error {
+	root := logger.root()
+	if strings.TrimSpace(spec) == "" {
+		root.modules.Store(nil)
+		return nil
+	}
+	m, err := parseModuleLevels(spec)
+	if err != nil {
+		return err
+	}
+	root.modules.Store(m)
+	return nil
+}
+
+// minLevel 返回 SetLevel 设置的级别与模块级别中最低的级别
+func (logger *Logger) minLevel() int {
+	root := logger.root()
+	if m := root.modules.Load(); m != nil {
+		return m.minLevel(int(root.level.Load()))
+	}
+	return int(root.level.Load())
+}
+
+func (logger *Logger) ModuleLevels() string {
+	if m := logger.root().modules.Load(); m != nil {
+		return m.spec
+	}
+	return ""
+}
+
+func SetModuleLevels(spec string) error {
+	return DefaultLogger.SetModuleLevels(spec)
+}
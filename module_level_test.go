@@ -0,0 +1,64 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestModuleLevels(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := NewLogger()
+	logger.SetOutput(buf)
+	if err := logger.SetModuleLevels("*=warn, db=debug, db.cache=error"); err != nil {
+		t.Fatal(err)
+	}
+
+	logger.Info("root info")
+	logger.Named("db").Debug("db debug")
+	logger.Named("db").Named("cache").Warn("cache warn")
+	logger.Named("dbx").Info("dbx info")
+	logger.Warn("root warn")
+
+	if s := buf.String(); !strings.Contains(s, "db debug") || !strings.Contains(s, "root warn") || strings.Contains(s, "info") || strings.Contains(s, "cache warn") {
+		t.Fatalf("unexpected output: %q", s)
+	}
+
+	// 按调用者包路径匹配
+	buf.Reset()
+	logger.SetModuleLevels("*=none,temprory/log=info")
+	logger.Info("pkg info")
+	logger.Debug("pkg debug")
+	if s := buf.String(); !strings.Contains(s, "pkg info") || strings.Contains(s, "pkg debug") {
+		t.Fatalf("unexpected output: %q", s)
+	}
+
+	if err := logger.SetModuleLevels("db=verbose"); err == nil {
+		t.Fatalf("invalid level should fail")
+	}
+	logger.SetModuleLevels("")
+	if logger.ModuleLevels() != "" {
+		t.Fatalf("module levels should be cleared")
+	}
+}
+
+func TestMatchModule(t *testing.T) {
+	for _, c := range []struct {
+		key, pattern string
+		want         bool
+	}{
+		{"github.com/a/myapp/db", "myapp/db", true},
+		{"github.com/a/myapp/db/sub", "myapp/db", true},
+		{"github.com/a/myapp/dbx", "myapp/db", false},
+		{"github.com/a/xmyapp/db", "myapp/db", false},
+		{"db.pool", "db", true},
+		{"mydb", "db", false},
+	} {
+		if got := matchModule(c.key, c.pattern); got != c.want {
+			t.Fatalf("matchModule(%q, %q) = %v", c.key, c.pattern, got)
+		}
+	}
+	if p := funcPackage("github.com/a/b.(*T).Method"); p != "github.com/a/b" {
+		t.Fatalf("funcPackage = %q", p)
+	}
+}
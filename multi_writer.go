@@ -0,0 +1,160 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var DefaultWriterRetryInterval = 10 * time.Second
+
+// WriterError 为 LogWriter 中某个 Writer 返回的错误
+type WriterError struct {
+	Index  int // Writer 在 MultiLogWriter 参数中的位置
+	Writer ILogWriter
+	Err    error
+}
+
+func (e *WriterError) Error() string {
+	return fmt.Sprintf("log writer %d (%T) failed: %v", e.Index, e.Writer, e.Err)
+}
+
+func (e *WriterError) Unwrap() error {
+	return e.Err
+}
+
+// WriterHealth 为 LogWriter 中某个 Writer 的状态
+type WriterHealth struct {
+	Index               int
+	Writer              ILogWriter
+	Writes              uint64 // 成功次数
+	Failures            uint64 // 失败次数
+	ConsecutiveFailures int
+	Skipped             uint64 // 暂停期间跳过的日志数
+	LastError           error
+	LastErrorTime       time.Time
+	DisabledUntil       time.Time // 非零时为暂停写入，到该时间后重试一次
+}
+
+func (h *WriterHealth) Disabled() bool {
+	return !h.DisabledUntil.IsZero()
+}
+
+type multiWriter struct {
+	sync.Mutex
+	health WriterHealth
+}
+
+// LogWriter 将日志依次写入多个 ILogWriter，由 MultiLogWriter 创建，配置项须在使用前设置
+type LogWriter struct {
+	writers []*multiWriter
+
+	// FailFast 为 true 时某个 Writer 出错后不再写入后面的 Writer
+	FailFast bool
+	// MaxFailures 为连续失败多少次后暂停写入该 Writer，0 表示不暂停
+	MaxFailures int
+	// RetryInterval 为暂停的 Writer 的重试间隔，0 时为 DefaultWriterRetryInterval
+	RetryInterval time.Duration
+}
+
+// WriteLog 返回写入的字节数之和，以及各 Writer 的 *WriterError 合并后的错误
+func (w *LogWriter) WriteLog(log *Log) (n int, err error) {
+	var errs []error
+	for _, v := range w.writers {
+		now := time.Now()
+		if !v.allow(now, w.retryInterval()) {
+			continue
+		}
+		nw, err := v.health.Writer.WriteLog(log)
+		n += nw
+		v.record(err, now, w.MaxFailures, w.retryInterval())
+		if err != nil {
+			errs = append(errs, &WriterError{Index: v.health.Index, Writer: v.health.Writer, Err: err})
+			if w.FailFast {
+				break
+			}
+		}
+	}
+	return n, errors.Join(errs...)
+}
+
+func (w *LogWriter) retryInterval() time.Duration {
+	if w.RetryInterval > 0 {
+		return w.RetryInterval
+	}
+	return DefaultWriterRetryInterval
+}
+
+// allow 判断是否写入，暂停到期后只放行一次重试，重试期间的其它日志仍跳过
+func (v *multiWriter) allow(now time.Time, retry time.Duration) bool {
+	v.Lock()
+	defer v.Unlock()
+	if v.health.DisabledUntil.IsZero() {
+		return true
+	}
+	if now.Before(v.health.DisabledUntil) {
+		v.health.Skipped++
+		return false
+	}
+	v.health.DisabledUntil = now.Add(retry)
+	return true
+}
+
+func (v *multiWriter) record(err error, now time.Time, maxFailures int, retry time.Duration) {
+	v.Lock()
+	defer v.Unlock()
+	if err == nil {
+		v.health.Writes++
+		v.health.ConsecutiveFailures = 0
+		v.health.DisabledUntil = time.Time{}
+		return
+	}
+	v.health.Failures++
+	v.health.ConsecutiveFailures++
+	v.health.LastError, v.health.LastErrorTime = err, now
+	if maxFailures > 0 && v.health.ConsecutiveFailures >= maxFailures {
+		v.health.DisabledUntil = now.Add(retry)
+	}
+}
+
+// Health 返回各 Writer 的状态
+func (w *LogWriter) Health() []WriterHealth {
+	arr := make([]WriterHealth, len(w.writers))
+	for i, v := range w.writers {
+		v.Lock()
+		arr[i] = v.health
+		v.Unlock()
+	}
+	return arr
+}
+
+// Enable 立即恢复暂停的第 i 个 Writer 并清零连续失败次数
+func (w *LogWriter) Enable(i int) {
+	if i < 0 || i >= len(w.writers) {
+		return
+	}
+	v := w.writers[i]
+	v.Lock()
+	v.health.ConsecutiveFailures = 0
+	v.health.DisabledUntil = time.Time{}
+	v.Unlock()
+}
+
+func (w *LogWriter) Sync() error {
+	var errs []error
+	for _, v := range w.writers {
+		if err := syncWriter(v.health.Writer); err != nil {
+			errs = append(errs, &WriterError{Index: v.health.Index, Writer: v.health.Writer, Err: err})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func MultiLogWriter(writers ...ILogWriter) *LogWriter {
+	w := &LogWriter{}
+	for i, v := range writers {
+		w.writers = append(w.writers, &multiWriter{health: WriterHealth{Index: i, Writer: v}})
+	}
+	return w
+}
@@ -0,0 +1,88 @@
+package log
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type failLogWriter struct {
+	fail   bool
+	writes int
+}
+
+func (w *failLogWriter) WriteLog(log *Log) (n int, err error) {
+	w.writes++
+	if w.fail {
+		return 0, errors.New("disk full")
+	}
+	return len(log.Value), nil
+}
+
+func TestMultiLogWriterErrors(t *testing.T) {
+	a, b, c := &failLogWriter{fail: true}, &failLogWriter{}, &failLogWriter{fail: true}
+	mw := MultiLogWriter(a, b, c)
+
+	var handled error
+	logger := NewLogger()
+	logger.SetOutput(nil)
+	logger.SetStructOutput(mw)
+	logger.WriteErrorHandler = func(err error) { handled = err }
+	logger.Info("x")
+
+	var we *WriterError
+	if !errors.As(handled, &we) || we.Index != 0 || we.Writer != a {
+		t.Fatalf("unexpected error: %v", handled)
+	}
+	if n, err := mw.WriteLog(&Log{Value: "abc"}); n != 3 || err == nil || b.writes != 2 || c.writes != 2 {
+		t.Fatalf("n = %d, err = %v, writes %d %d", n, err, b.writes, c.writes)
+	}
+
+	mw.FailFast = true
+	if _, err := mw.WriteLog(&Log{Value: "abc"}); err == nil || b.writes != 2 {
+		t.Fatalf("fail fast should stop at first error: %v, %d", err, b.writes)
+	}
+}
+
+func TestMultiLogWriterDisable(t *testing.T) {
+	a, b := &failLogWriter{fail: true}, &failLogWriter{}
+	mw := MultiLogWriter(a, b)
+	mw.MaxFailures = 2
+	mw.RetryInterval = 50 * time.Millisecond
+
+	for i := 0; i < 5; i++ {
+		mw.WriteLog(&Log{Value: "x"})
+	}
+	h := mw.Health()
+	if a.writes != 2 || !h[0].Disabled() || h[0].Skipped != 3 || h[0].ConsecutiveFailures != 2 || h[0].LastError == nil {
+		t.Fatalf("writer should be disabled: writes %d, %+v", a.writes, h[0])
+	}
+	if h[1].Writes != 5 || h[1].Disabled() {
+		t.Fatalf("healthy writer: %+v", h[1])
+	}
+
+	// 重试仍失败时继续暂停
+	time.Sleep(60 * time.Millisecond)
+	mw.WriteLog(&Log{Value: "x"})
+	mw.WriteLog(&Log{Value: "x"})
+	if a.writes != 3 || !mw.Health()[0].Disabled() {
+		t.Fatalf("retry: writes %d, %+v", a.writes, mw.Health()[0])
+	}
+
+	// 重试成功后恢复
+	a.fail = false
+	time.Sleep(60 * time.Millisecond)
+	mw.WriteLog(&Log{Value: "x"})
+	mw.WriteLog(&Log{Value: "x"})
+	if h := mw.Health()[0]; a.writes != 5 || h.Disabled() || h.ConsecutiveFailures != 0 || h.Writes != 2 {
+		t.Fatalf("recover: writes %d, %+v", a.writes, h)
+	}
+
+	a.fail = true
+	mw.WriteLog(&Log{Value: "x"})
+	mw.WriteLog(&Log{Value: "x"})
+	mw.Enable(0)
+	if mw.Health()[0].Disabled() {
+		t.Fatalf("Enable should resume writer")
+	}
+}
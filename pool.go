@@ -0,0 +1,39 @@
+package log
+
+import "sync"
+
+// 超过该容量的 buffer 不放回池中，以免偶尔的大日志长期占用内存
+const maxPooledBufferSize = 64 << 10
+
+var (
+	bufferPool = sync.Pool{New: func() interface{} {
+		b := make([]byte, 0, 512)
+		return &b
+	}}
+	logPool = sync.Pool{New: func() interface{} {
+		return &Log{}
+	}}
+)
+
+func getBuffer() *[]byte {
+	b := bufferPool.Get().(*[]byte)
+	*b = (*b)[:0]
+	return b
+}
+
+func putBuffer(b *[]byte) {
+	if cap(*b) > maxPooledBufferSize {
+		return
+	}
+	bufferPool.Put(b)
+}
+
+// getLog 从池中取出 Log，WriteLog 返回后由 putLog 回收，ILogWriter 需要保留时应复制
+func getLog() *Log {
+	return logPool.Get().(*Log)
+}
+
+func putLog(log *Log) {
+	*log = Log{}
+	logPool.Put(log)
+}
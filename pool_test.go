@@ -0,0 +1,96 @@
+package log
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAppendFormaterNoAlloc(t *testing.T) {
+	log := &Log{
+		Now:    time.Now(),
+		Level:  LEVEL_INFO,
+		File:   "pool_test.go",
+		Line:   1,
+		Value:  "hello",
+		Name:   "db",
+		Fields: []Field{F("n", 42), F("s", "a b"), F("ok", true)},
+	}
+	buf := make([]byte, 0, 1024)
+	for name, f := range map[string]func([]byte, *Log) []byte{
+		"text":   func(buf []byte, log *Log) []byte { return appendText(buf, log, DefaultLogTimeLayout) },
+		"color":  func(buf []byte, log *Log) []byte { return appendColorText(buf, log, DefaultLogTimeLayout) },
+		"json":   NewJSONFormater().Append,
+		"logfmt": NewLogfmtFormater().Append,
+	} {
+		if n := testing.AllocsPerRun(100, func() { buf = f(buf[:0], log) }); n != 0 {
+			t.Errorf("%s: %v allocs per log", name, n)
+		}
+	}
+}
+
+func TestAppendFormaterCompat(t *testing.T) {
+	log := &Log{Now: time.Now(), Level: LEVEL_WARN, File: "a.go", Line: 3, Value: "x", Fields: []Field{F("f", 1.5)}}
+	f := NewJSONFormater()
+	if s := f.Format(log); s != string(f.Append([]byte{}, log)) || !strings.HasSuffix(s, `"f":1.5}`) {
+		t.Fatalf("unexpected json: %q", s)
+	}
+	if s := formatText(log, DefaultLogTimeLayout); !strings.HasSuffix(s, " [ Warn] [a.go:3] x f=1.5") {
+		t.Fatalf("unexpected text: %q", s)
+	}
+}
+
+func benchmarkLogger(b *testing.B, f func(logger *Logger)) {
+	logger := NewLogger()
+	logger.SetOutput(ioutil.Discard)
+	f(logger)
+}
+
+func BenchmarkInfoKVNoFields(b *testing.B) {
+	benchmarkLogger(b, func(logger *Logger) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			logger.InfoKV("hello")
+		}
+	})
+}
+
+func BenchmarkInfoKV(b *testing.B) {
+	benchmarkLogger(b, func(logger *Logger) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			logger.InfoKV("hello", "user", "bob", "n", i)
+		}
+	})
+}
+
+func BenchmarkInfof(b *testing.B) {
+	benchmarkLogger(b, func(logger *Logger) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			logger.Info("hello %s %d", "bob", i)
+		}
+	})
+}
+
+func BenchmarkJSONAppend(b *testing.B) {
+	benchmarkLogger(b, func(logger *Logger) {
+		logger.SetAppendFormater(NewJSONFormater().Append)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			logger.InfoKV("hello")
+		}
+	})
+}
+
+// BenchmarkJSONFormat 为兼容的 string 格式化函数，用于对比
+func BenchmarkJSONFormat(b *testing.B) {
+	benchmarkLogger(b, func(logger *Logger) {
+		logger.SetFormater(NewJSONFormater().Format)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			logger.InfoKV("hello")
+		}
+	})
+}
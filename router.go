@@ -0,0 +1,170 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+const (
+	ROUTE_FIRST_MATCH = iota // 只写入第一个匹配的路由
+	ROUTE_ALL_MATCH          // 写入所有匹配的路由
+)
+
+// Route 为 RouteLogWriter 的一条路由，所有非空的条件都满足时匹配，条件都为空时匹配所有日志
+type Route struct {
+	MinLevel int // 级别下限(包含)，0 表示不限
+	MaxLevel int // 级别上限(包含)，0 表示不限
+	// Names 为 logger 名字，与 SetModuleLevels 的规则相同，"db" 匹配 "db" 和 "db.pool"
+	Names []string
+	// Packages 为调用者的包路径，"myapp/db" 匹配 "github.com/x/myapp/db" 及其子包
+	Packages []string
+	// Match 为自定义条件，如 FieldEquals("audit", true)
+	Match  func(log *Log) bool
+	Writer ILogWriter
+}
+
+func (r *Route) match(log *Log) bool {
+	if r.MinLevel > 0 && log.Level < r.MinLevel || r.MaxLevel > 0 && log.Level > r.MaxLevel {
+		return false
+	}
+	if len(r.Names) > 0 && !matchAny(log.Name, r.Names) {
+		return false
+	}
+	if len(r.Packages) > 0 && (log.PC == 0 || !matchAny(lookupCaller(log.PC).pkg, r.Packages)) {
+		return false
+	}
+	return r.Match == nil || r.Match(log)
+}
+
+func matchAny(key string, patterns []string) bool {
+	if key == "" {
+		return false
+	}
+	for _, p := range patterns {
+		if matchModule(key, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// FieldEquals 返回判断 log 中 key 字段的值是否等于 value 的条件，值不可比较时按 fmt.Sprint 的结果比较
+func FieldEquals(key string, value interface{}) func(log *Log) bool {
+	return func(log *Log) bool {
+		for i := len(log.Fields) - 1; i >= 0; i-- {
+			if f := log.Fields[i]; f.Key == key {
+				return fieldEqual(f.Value, value)
+			}
+		}
+		return false
+	}
+}
+
+func fieldEqual(a, b interface{}) (eq bool) {
+	defer func() {
+		if recover() != nil {
+			eq = fmt.Sprint(a) == fmt.Sprint(b)
+		}
+	}()
+	return a == b
+}
+
+// RouteLogWriter 按级别范围、logger 名字、调用者包路径或自定义条件将日志分发到不同的 ILogWriter，
+// 都不匹配时写入 Default(为 nil 时丢弃)，可与 FileWriter、IOLogWriter 等组合使用:
+//
+//	NewRouteLogWriter(ROUTE_ALL_MATCH, nil,
+//		Route{MinLevel: LEVEL_ERROR, Writer: errorFile},
+//		Route{MaxLevel: LEVEL_DEBUG, Writer: NewIOLogWriter(os.Stdout)},
+//	)
+type RouteLogWriter struct {
+	Mode    int // ROUTE_FIRST_MATCH 或 ROUTE_ALL_MATCH
+	Routes  []Route
+	Default ILogWriter
+}
+
+func NewRouteLogWriter(mode int, def ILogWriter, routes ...Route) *RouteLogWriter {
+	return &RouteLogWriter{Mode: mode, Routes: routes, Default: def}
+}
+
+// WriteLog 返回写入的字节数之和及各 Writer 的错误
+func (w *RouteLogWriter) WriteLog(log *Log) (n int, err error) {
+	var (
+		errs    []error
+		matched bool
+	)
+	for i := range w.Routes {
+		r := &w.Routes[i]
+		if r.Writer == nil || !r.match(log) {
+			continue
+		}
+		matched = true
+		nw, err := r.Writer.WriteLog(log)
+		n += nw
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if w.Mode == ROUTE_FIRST_MATCH {
+			break
+		}
+	}
+	if !matched && w.Default != nil {
+		return w.Default.WriteLog(log)
+	}
+	return n, errors.Join(errs...)
+}
+
+func (w *RouteLogWriter) Sync() error {
+	var errs []error
+	for _, r := range w.Routes {
+		if err := syncWriter(r.Writer); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := syncWriter(w.Default); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// IOLogWriter 将日志格式化后写入 io.Writer，如 os.Stdout，用于在 RouteLogWriter 中组合普通的 Writer
+type IOLogWriter struct {
+	sync.Mutex
+	Writer io.Writer
+	// AppendFormater 为空时使用默认的文本格式
+	AppendFormater func(buf []byte, log *Log) []byte
+}
+
+func NewIOLogWriter(out io.Writer) *IOLogWriter {
+	return &IOLogWriter{Writer: out}
+}
+
+func (w *IOLogWriter) WriteLog(log *Log) (n int, err error) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	switch {
+	case w.AppendFormater != nil:
+		*buf = w.AppendFormater(*buf, log)
+	case log.Level == LEVEL_PRINT:
+		*buf = append(*buf, log.Value...)
+	default:
+		layout, fullPath := DefaultLogTimeLayout, false
+		if log.Logger != nil {
+			layout, fullPath = log.Logger.Layout, log.Logger.FullPath
+		}
+		log.Caller(fullPath)
+		*buf = appendText(*buf, log, layout)
+	}
+	if b := *buf; log.Level != LEVEL_PRINT && len(b) > 0 && b[len(b)-1] != '\n' {
+		*buf = append(b, '\n')
+	}
+
+	w.Lock()
+	defer w.Unlock()
+	return w.Writer.Write(*buf)
+}
+
+func (w *IOLogWriter) Sync() error {
+	return syncWriter(w.Writer)
+}
@@ -0,0 +1,68 @@
+package log
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestRouteLogWriter(t *testing.T) {
+	dir := t.TempDir() + "/"
+	errorFile := &FileWriter{RootDir: dir, FileFormat: "error.log"}
+	defer errorFile.Close()
+	stdout := &bytes.Buffer{}
+	db, audit, rest := &testLogWriter{}, &testLogWriter{}, &testLogWriter{}
+
+	router := NewRouteLogWriter(ROUTE_ALL_MATCH, rest,
+		Route{MinLevel: LEVEL_ERROR, Writer: errorFile},
+		Route{MaxLevel: LEVEL_DEBUG, Writer: NewIOLogWriter(stdout)},
+		Route{Names: []string{"db"}, Packages: []string{"temprory/log"}, Writer: db},
+		Route{Match: FieldEquals("audit", true), Writer: audit},
+	)
+	logger := NewLogger()
+	logger.SetOutput(nil)
+	logger.SetLevel(LEVEL_DEBUG)
+	logger.SetStructOutput(router)
+
+	logger.Debug("debug")
+	logger.Named("db.pool").Error("db error")
+	logger.InfoKV("login", "audit", true)
+	logger.Named("dbx").Info("other")
+
+	data, err := ioutil.ReadFile(dir + "error.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := string(data); strings.Count(s, "\n") != 1 || !strings.Contains(s, "[router_test.go:") || !strings.HasSuffix(s, "db error\n") {
+		t.Fatalf("unexpected error file: %q", s)
+	}
+	if s := stdout.String(); strings.Count(s, "\n") != 1 || !strings.HasSuffix(s, "] debug\n") {
+		t.Fatalf("unexpected stdout: %q", s)
+	}
+	if len(db.logs) != 1 || db.logs[0].Value != "db error" {
+		t.Fatalf("unexpected db logs: %d", len(db.logs))
+	}
+	if len(audit.logs) != 1 || audit.logs[0].Value != "login" {
+		t.Fatalf("unexpected audit logs: %d", len(audit.logs))
+	}
+	if len(rest.logs) != 1 || rest.logs[0].Value != "other" {
+		t.Fatalf("unexpected default logs: %d", len(rest.logs))
+	}
+
+	// 第一个匹配的路由
+	first, second := &testLogWriter{}, &testLogWriter{}
+	logger.SetStructOutput(NewRouteLogWriter(ROUTE_FIRST_MATCH, nil,
+		Route{MinLevel: LEVEL_INFO, MaxLevel: LEVEL_WARN, Writer: first},
+		Route{Writer: second},
+	))
+	logger.Warn("w")
+	logger.Error("e")
+	logger.Info("i")
+	if len(first.logs) != 2 || len(second.logs) != 1 || second.logs[0].Value != "e" {
+		t.Fatalf("first match: %d, %d", len(first.logs), len(second.logs))
+	}
+	if err := logger.Sync(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,222 @@
+package log
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+var DefaultSamplingInterval = time.Second
+
+// SamplingOptions 为 SetSampling 的配置，Panic/Fatal 日志不受影响
+type SamplingOptions struct {
+	// Interval 为采样周期，也是输出被丢弃日志数量汇总的周期，0 时为 DefaultSamplingInterval
+	Interval time.Duration
+	// 每个分组每个周期内前 First 条全部输出，之后每 Thereafter 条输出 1 条(为 0 时全部丢弃)，
+	// First 和 Thereafter 都为 0 时不采样
+	First      int
+	Thereafter int
+	// ByMessage 为 true 时按级别+格式串(KV 方法为 msg)分组，否则按级别+调用位置分组，没有调用位置时按消息分组
+	ByMessage bool
+	// RateLimits 为各级别的令牌桶限流，在采样之前进行
+	RateLimits map[int]RateLimit
+}
+
+// RateLimit 为每秒 PerSecond 条、容量 Burst(<= 0 时为 PerSecond，至少为 1)的令牌桶
+type RateLimit struct {
+	PerSecond float64
+	Burst     int
+}
+
+type sampleKey struct {
+	level  int
+	pc     uintptr
+	format string
+}
+
+type sampleCounter struct {
+	n       int
+	dropped int
+}
+
+type tokenBucket struct {
+	RateLimit
+	tokens  float64
+	last    time.Time
+	dropped int
+}
+
+func (b *tokenBucket) take(now time.Time) bool {
+	burst := float64(b.Burst)
+	if burst <= 0 {
+		burst = b.PerSecond
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	if b.last.IsZero() {
+		b.tokens = burst
+	} else if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * b.PerSecond
+		if b.tokens > burst {
+			b.tokens = burst
+		}
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+type sampler struct {
+	opts   SamplingOptions
+	logger *Logger
+
+	mu       sync.Mutex
+	counters map[sampleKey]*sampleCounter
+	buckets  map[int]*tokenBucket
+
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+func newSampler(logger *Logger, opts SamplingOptions) *sampler {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultSamplingInterval
+	}
+	s := &sampler{
+		opts:     opts,
+		logger:   logger,
+		counters: map[sampleKey]*sampleCounter{},
+		buckets:  map[int]*tokenBucket{},
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	for level, limit := range opts.RateLimits {
+		if limit.PerSecond > 0 {
+			s.buckets[level] = &tokenBucket{RateLimit: limit}
+		}
+	}
+	go s.run()
+	return s
+}
+
+// allow 判断日志是否输出，被丢弃的日志计入下一次汇总
+func (s *sampler) allow(level int, pc uintptr, format string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if b := s.buckets[level]; b != nil && !b.take(time.Now()) {
+		b.dropped++
+		return false
+	}
+	if s.opts.First <= 0 && s.opts.Thereafter <= 0 {
+		return true
+	}
+
+	key := sampleKey{level: level}
+	// 没有调用位置(如标准库 log 转入的日志)时按消息分组
+	if s.opts.ByMessage || pc == 0 {
+		key.format = format
+	} else {
+		key.pc = pc
+	}
+	c := s.counters[key]
+	if c == nil {
+		c = &sampleCounter{}
+		s.counters[key] = c
+	}
+	c.n++
+	if c.n > s.opts.First && (s.opts.Thereafter <= 0 || (c.n-s.opts.First)%s.opts.Thereafter != 0) {
+		c.dropped++
+		return false
+	}
+	return true
+}
+
+func (s *sampler) run() {
+	defer close(s.stopped)
+	ticker := time.NewTicker(s.opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.flush()
+		case <-s.done:
+			s.flush()
+			return
+		}
+	}
+}
+
+// flush 开始新的采样周期，并为有日志被丢弃的分组和级别各输出一条汇总日志
+func (s *sampler) flush() {
+	var summaries []*Log
+	now := time.Now()
+
+	s.mu.Lock()
+	for key, c := range s.counters {
+		if c.dropped > 0 {
+			log := &Log{Now: now, Level: key.level, PC: key.pc, Fields: []Field{F("suppressed", c.dropped)}}
+			log.Value = "suppressed " + strconv.Itoa(c.dropped) + " similar messages"
+			if key.pc == 0 {
+				log.Fields = append(log.Fields, F("sampled", key.format))
+			}
+			summaries = append(summaries, log)
+		}
+		// 上个周期没有日志的分组不再保留
+		if c.n == 0 {
+			delete(s.counters, key)
+		}
+		c.n, c.dropped = 0, 0
+	}
+	for level, b := range s.buckets {
+		if b.dropped > 0 {
+			summaries = append(summaries, &Log{
+				Now:    now,
+				Level:  level,
+				Value:  "suppressed " + strconv.Itoa(b.dropped) + " " + LevelText(level) + " messages by rate limit",
+				Fields: []Field{F("suppressed", b.dropped)},
+			})
+			b.dropped = 0
+		}
+	}
+	s.mu.Unlock()
+
+	for _, log := range summaries {
+		if log.PC == 0 {
+			log.File, log.Line = "sampler", 0
+		}
+		s.logger.writeLog(log)
+	}
+}
+
+// sample 判断日志是否通过 SetSampling 设置的采样和限流，Panic/Fatal 不采样
+func (logger *Logger) sample(level int, pc uintptr, format string) bool {
+	s := logger.root().sampler.Load()
+	return s == nil || level >= LEVEL_PANIC || s.allow(level, pc, format)
+}
+
+func (s *sampler) stop() {
+	close(s.done)
+	<-s.stopped
+}
+
+// SetSampling 设置采样和按级别限流，opts 为 nil 时关闭，
+// 被丢弃的日志数量每个周期以 "suppressed N similar messages" 的汇总日志输出
+func (logger *Logger) SetSampling(opts *SamplingOptions) {
+	root := logger.root()
+	var s *sampler
+	if opts != nil {
+		s = newSampler(root, *opts)
+	}
+	if old := root.sampler.Swap(s); old != nil {
+		old.stop()
+	}
+}
+
+func SetSampling(opts *SamplingOptions) {
+	DefaultLogger.SetSampling(opts)
+}
@@ -0,0 +1,126 @@
+package log
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type syncBuffer struct {
+	sync.Mutex
+	bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.Lock()
+	defer b.Unlock()
+	return b.Buffer.Write(p)
+}
+
+func (b *syncBuffer) lines() []string {
+	b.Lock()
+	defer b.Unlock()
+	return strings.Split(strings.TrimSpace(b.String()), "\n")
+}
+
+func TestSampling(t *testing.T) {
+	buf := &syncBuffer{}
+	logger := NewLogger()
+	logger.SetOutput(buf)
+	logger.SetSampling(&SamplingOptions{Interval: time.Hour, First: 3, Thereafter: 10})
+
+	for i := 0; i < 25; i++ {
+		logger.Warn("hot %d", i)
+	}
+	logger.Info("other")
+	logger.Error("other")
+	logger.SetSampling(nil)
+
+	// 前 3 条，第 13、23 条，另外两条，汇总一条
+	lines := buf.lines()
+	if len(lines) != 8 {
+		t.Fatalf("unexpected output: %q", lines)
+	}
+	if !strings.HasSuffix(lines[3], "hot 12") || !strings.HasSuffix(lines[4], "hot 22") {
+		t.Fatalf("unexpected sampled lines: %q", lines)
+	}
+	if s := lines[7]; !strings.Contains(s, "[ Warn] [sampler_test.go:") || !strings.HasSuffix(s, "suppressed 20 similar messages suppressed=20") {
+		t.Fatalf("unexpected summary: %q", s)
+	}
+
+	logger.Warn("after")
+	if lines := buf.lines(); !strings.HasSuffix(lines[len(lines)-1], "after") {
+		t.Fatalf("sampling should be disabled: %q", lines)
+	}
+}
+
+func TestSamplingByMessage(t *testing.T) {
+	buf := &syncBuffer{}
+	logger := NewLogger()
+	logger.SetOutput(buf)
+	logger.SetSampling(&SamplingOptions{Interval: 20 * time.Millisecond, First: 1, ByMessage: true})
+	defer logger.SetSampling(nil)
+
+	for i := 0; i < 3; i++ {
+		logger.WarnKV("retry", "n", i)
+		logger.WarnKV("retry", "n", i)
+	}
+	time.Sleep(50 * time.Millisecond)
+	logger.WarnKV("retry", "n", 9)
+
+	lines := buf.lines()
+	if len(lines) != 3 || !strings.HasSuffix(lines[1], "suppressed 5 similar messages suppressed=5 sampled=retry") || !strings.HasSuffix(lines[2], "retry n=9") {
+		t.Fatalf("unexpected output: %q", lines)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	buf := &syncBuffer{}
+	logger := NewLogger()
+	logger.SetOutput(buf)
+	logger.ExitFunc = func(int) {}
+	logger.SetSampling(&SamplingOptions{
+		Interval:   time.Hour,
+		RateLimits: map[int]RateLimit{LEVEL_INFO: {PerSecond: 0.001, Burst: 2}, LEVEL_FATAL: {PerSecond: 0.001, Burst: 1}},
+	})
+	for i := 0; i < 10; i++ {
+		logger.Info("i %d", i)
+		logger.Warn("w %d", i)
+	}
+	logger.Fatal("f1")
+	logger.Fatal("f2")
+	logger.SetSampling(nil)
+
+	lines := buf.lines()
+	if len(lines) != 2+10+2+1 || !strings.HasSuffix(lines[len(lines)-1], "suppressed 8 Info messages by rate limit suppressed=8") {
+		t.Fatalf("unexpected output: %q", lines)
+	}
+}
+
+func TestSamplingBridges(t *testing.T) {
+	buf := &syncBuffer{}
+	logger := NewLogger()
+	logger.SetOutput(buf)
+	logger.SetSampling(&SamplingOptions{Interval: time.Hour, First: 1})
+
+	// slog 按 r.PC 分组，标准库 log 没有调用位置时按消息分组
+	sl := slog.New(NewSlogHandler(logger))
+	std := logger.StdLogger(LEVEL_WARN)
+	for i := 0; i < 5; i++ {
+		sl.Warn("slog hot")
+		std.Print("std hot")
+	}
+	logger.SetSampling(nil)
+
+	lines := buf.lines()
+	if len(lines) != 4 || !strings.HasSuffix(lines[0], "slog hot") || !strings.HasSuffix(lines[1], "std hot") {
+		t.Fatalf("unexpected output: %q", lines)
+	}
+	summaries := strings.Join(lines[2:], "\n")
+	if !strings.Contains(summaries, "[sampler_test.go:") || !strings.Contains(summaries, `suppressed=4 sampled="std hot"`) {
+		t.Fatalf("unexpected summaries: %q", lines)
+	}
+}
@@ -0,0 +1,150 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// SlogLevel 将 LEVEL_* 转换为 slog.Level
+func SlogLevel(lvl int) slog.Level {
+	switch {
+	case lvl < LEVEL_DEBUG:
+		return slog.LevelDebug - 4
+	case lvl < LEVEL_INFO:
+		return slog.LevelDebug
+	case lvl < LEVEL_WARN:
+		return slog.LevelInfo
+	case lvl < LEVEL_ERROR:
+		return slog.LevelWarn
+	}
+	return slog.LevelError
+}
+
+// LevelFromSlog 将 slog.Level 转换为 LEVEL_*
+func LevelFromSlog(level slog.Level) int {
+	switch {
+	case level < slog.LevelDebug:
+		return LEVEL_TRACE
+	case level < slog.LevelInfo:
+		return LEVEL_DEBUG
+	case level < slog.LevelWarn:
+		return LEVEL_INFO
+	case level < slog.LevelError:
+		return LEVEL_WARN
+	}
+	return LEVEL_ERROR
+}
+
+// SlogHandler 是由 *Logger 实现的 slog.Handler，attr 转为 Field，group 以 "." 连接为字段名前缀
+type SlogHandler struct {
+	logger *Logger
+	group  string
+}
+
+func NewSlogHandler(logger *Logger) *SlogHandler {
+	return &SlogHandler{logger: logger}
+}
+
+func (h *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	// 可能有按包路径设置的更低级别，精确判断在 Handle 中按 r.PC 进行
+	return LevelFromSlog(level) >= h.logger.minLevel()
+}
+
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	if !h.logger.enabledPC(LevelFromSlog(r.Level), r.PC) || !h.logger.sample(LevelFromSlog(r.Level), r.PC, r.Message) {
+		return nil
+	}
+	ctxFields := ContextFields(ctx)
+	fields := make([]Field, 0, len(h.logger.fields)+len(ctxFields)+r.NumAttrs())
+	fields = append(fields, h.logger.fields...)
+	fields = append(fields, ctxFields...)
+	r.Attrs(func(a slog.Attr) bool {
+		fields = appendSlogAttr(fields, h.group, a)
+		return true
+	})
+
+	log := getLog()
+	*log = Log{
+		Now:    r.Time,
+		PC:     r.PC,
+		Level:  LevelFromSlog(r.Level),
+		Value:  r.Message,
+		Name:   h.logger.name,
+		Fields: fields,
+	}
+	if log.Now.IsZero() {
+		log.Now = time.Now()
+	}
+	// 调用位置在 slog 内部，只使用 attrs 中 error 携带的调用栈
+	if root := h.logger.root(); root.StackLevel > 0 && log.Level >= root.StackLevel {
+		log.Stack = argsStack(fieldsArgs(fields))
+	}
+	h.logger.writeLog(log)
+	putLog(log)
+	return nil
+}
+
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var fields []Field
+	for _, a := range attrs {
+		fields = appendSlogAttr(fields, h.group, a)
+	}
+	return &SlogHandler{logger: h.logger.With(fields), group: h.group}
+}
+
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &SlogHandler{logger: h.logger, group: h.group + name + "."}
+}
+
+func appendSlogAttr(fields []Field, prefix string, a slog.Attr) []Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		attrs := a.Value.Group()
+		if len(attrs) == 0 {
+			return fields
+		}
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, v := range attrs {
+			fields = appendSlogAttr(fields, prefix, v)
+		}
+		return fields
+	}
+	return append(fields, Field{Key: prefix + a.Key, Value: a.Value.Any()})
+}
+
+// SlogLogWriter 将日志转发给 slog.Handler，可作为 Logger 的 LogWriter 使用
+type SlogLogWriter struct {
+	Handler slog.Handler
+}
+
+func NewSlogLogWriter(h slog.Handler) *SlogLogWriter {
+	return &SlogLogWriter{Handler: h}
+}
+
+func (w *SlogLogWriter) WriteLog(log *Log) (n int, err error) {
+	ctx := context.Background()
+	level := SlogLevel(log.Level)
+	if !w.Handler.Enabled(ctx, level) {
+		return 0, nil
+	}
+	r := slog.NewRecord(log.Now, level, log.Value, log.PC)
+	if log.Name != "" {
+		r.AddAttrs(slog.String("logger", log.Name))
+	}
+	for _, f := range log.Fields {
+		r.AddAttrs(slog.Any(f.Key, f.Value))
+	}
+	if err = w.Handler.Handle(ctx, r); err != nil {
+		return 0, err
+	}
+	return len(log.Value), nil
+}
@@ -0,0 +1,46 @@
+package log
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSlogHandler(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := NewLogger()
+	logger.SetOutput(buf)
+	logger.SetLevel(LEVEL_INFO)
+
+	l := slog.New(NewSlogHandler(logger.Named("svc"))).With("a", 1).WithGroup("req")
+	l.Debug("hidden")
+	l.Info("hello", "id", 7, slog.Group("user", "name", "bob"))
+
+	line := strings.TrimSuffix(buf.String(), "\n")
+	if !strings.Contains(line, " [ Info] [svc] [slog_test.go:") || !strings.HasSuffix(line, "hello a=1 req.id=7 req.user.name=bob") {
+		t.Fatalf("unexpected output: %q", line)
+	}
+}
+
+func TestSlogLogWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := NewLogger()
+	logger.SetOutput(nil)
+	logger.SetStructOutput(NewSlogLogWriter(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelInfo})))
+
+	logger.Debug("hidden")
+	logger.Named("db").WarnKV("slow", "ms", 12)
+
+	if s := buf.String(); strings.Contains(s, "hidden") || !strings.Contains(s, `level=WARN msg=slow logger=db ms=12`) {
+		t.Fatalf("unexpected output: %q", s)
+	}
+
+	// 调用位置通过 PC 传给 slog.Handler
+	buf.Reset()
+	logger.SetStructOutput(NewSlogLogWriter(slog.NewTextHandler(buf, &slog.HandlerOptions{AddSource: true})))
+	logger.Info("src")
+	if s := buf.String(); !strings.Contains(s, "slog_test.go:") {
+		t.Fatalf("source not passed: %q", s)
+	}
+}
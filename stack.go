@@ -0,0 +1,139 @@
+package log
+
+import (
+	"errors"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+var DefaultStackDepth = 32
+
+// formatStack 按 panic 输出的格式输出调用栈:
+//
+//	main.foo
+//		/path/to/main.go:12
+func formatStack(pcs []uintptr) string {
+	if len(pcs) == 0 {
+		return ""
+	}
+	var (
+		frames = runtime.CallersFrames(pcs)
+		arr    = make([]string, 0, len(pcs))
+	)
+	for {
+		frame, more := frames.Next()
+		arr = append(arr, frame.Function+"\n\t"+frame.File+":"+strconv.Itoa(frame.Line))
+		if !more {
+			break
+		}
+	}
+	return strings.Join(arr, "\n")
+}
+
+// captureStack 从调用 captureStack 的函数往上第 skip 层开始记录最多 depth 层调用栈
+func captureStack(skip, depth int) string {
+	if depth <= 0 {
+		depth = DefaultStackDepth
+	}
+	pcs := make([]uintptr, depth)
+	n := runtime.Callers(skip+2, pcs)
+	return formatStack(pcs[:n])
+}
+
+// ErrorStack 沿 Unwrap 链查找带调用栈的 error，返回格式化后的调用栈，
+// 支持 StackTrace() 返回 []uintptr 或元素为 uintptr 类型的切片(如 github.com/pkg/errors)，
+// 以及 Stack() 返回 []byte/string 的 error
+func ErrorStack(err error) string {
+	var stack string
+	walkError(err, func(e error) bool {
+		stack = errorStack(e)
+		return stack == ""
+	})
+	return stack
+}
+
+// walkError 深度优先遍历 err 及其包装的 error，f 返回 false 时停止
+func walkError(err error, f func(error) bool) bool {
+	for err != nil {
+		if !f(err) {
+			return false
+		}
+		if multi, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, e := range multi.Unwrap() {
+				if !walkError(e, f) {
+					return false
+				}
+			}
+			return true
+		}
+		err = errors.Unwrap(err)
+	}
+	return true
+}
+
+func errorStack(err error) string {
+	switch e := err.(type) {
+	case interface{ Stack() []byte }:
+		return strings.TrimSpace(string(e.Stack()))
+	case interface{ Stack() string }:
+		return strings.TrimSpace(e.Stack())
+	}
+
+	m := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() != 1 {
+		return ""
+	}
+	out := m.Type().Out(0)
+	if out.Kind() != reflect.Slice || out.Elem().Kind() != reflect.Uintptr {
+		return ""
+	}
+	v := m.Call(nil)[0]
+	pcs := make([]uintptr, v.Len())
+	for i := range pcs {
+		pcs[i] = uintptr(v.Index(i).Uint())
+	}
+	return formatStack(pcs)
+}
+
+// argsStack 返回参数中第一个带调用栈的 error 的调用栈
+func argsStack(args []interface{}) string {
+	for _, v := range args {
+		switch x := v.(type) {
+		case error:
+			if s := ErrorStack(x); s != "" {
+				return s
+			}
+		case Field:
+			if err, ok := x.Value.(error); ok {
+				if s := ErrorStack(err); s != "" {
+					return s
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func fieldsArgs(fields []Field) []interface{} {
+	args := make([]interface{}, len(fields))
+	for i, f := range fields {
+		args[i] = f
+	}
+	return args
+}
+
+// SetStackTrace 设置 level 及以上级别的日志记录调用栈，depth 为最大层数(<= 0 时为 DefaultStackDepth)，
+// skip 为额外跳过的层数，level 为 0 或 LEVEL_NONE 时关闭。
+// 参数中有带调用栈的 error 时(见 ErrorStack)使用 error 的调用栈
+func (logger *Logger) SetStackTrace(level, depth, skip int) {
+	root := logger.root()
+	root.StackLevel = level
+	root.StackDepth = depth
+	root.StackSkip = skip
+}
+
+func SetStackTrace(level, depth, skip int) {
+	DefaultLogger.SetStackTrace(level, depth, skip)
+}
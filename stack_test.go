@@ -0,0 +1,51 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type stackError struct {
+	msg   string
+	stack string
+}
+
+func (e *stackError) Error() string { return e.msg }
+func (e *stackError) Stack() string { return e.stack }
+
+func TestStackTrace(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := NewLogger()
+	logger.SetOutput(buf)
+	logger.SetStackTrace(LEVEL_ERROR, 2, 0)
+
+	logger.Warn("no stack")
+	logger.Error("with stack")
+	out := buf.String()
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2+2*2 || !strings.Contains(lines[0], "no stack") {
+		t.Fatalf("unexpected output: %q", out)
+	}
+	if !strings.HasSuffix(lines[2], ".TestStackTrace") || !strings.Contains(lines[3], "stack_test.go:") {
+		t.Fatalf("stack should start at caller: %q", out)
+	}
+
+	buf.Reset()
+	err := fmt.Errorf("wrapped: %w", &stackError{"inner", "main.foo\n\t/x/main.go:1\n"})
+	logger.With("k", 1).ErrorKV("failed", "err", err)
+	if !strings.HasSuffix(buf.String(), "k=1 err=\"wrapped: inner\"\nmain.foo\n\t/x/main.go:1\n") {
+		t.Fatalf("error stack not used: %q", buf.String())
+	}
+
+	buf.Reset()
+	logger.SetFormater(NewJSONFormater().Format)
+	logger.Error("json %v", errors.Join(errors.New("a"), &stackError{"b", "s"}))
+	m := map[string]interface{}{}
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil || m["stack"] != "s" {
+		t.Fatalf("unexpected json: %q, %v", buf.String(), err)
+	}
+}
@@ -0,0 +1,100 @@
+package log
+
+import (
+	stdlog "log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StdWriter 是接收标准库 log 输出的 io.Writer，按 Flags/Prefix 解析出调用位置后以 Level 写入 Logger
+type StdWriter struct {
+	Logger *Logger
+	Level  int
+	Prefix string
+	Flags  int
+}
+
+func (w *StdWriter) Write(p []byte) (n int, err error) {
+	if !w.Logger.enabledPC(w.Level, 0) {
+		return len(p), nil
+	}
+	file, line, msg := parseStdLog(string(p), w.Prefix, w.Flags)
+	if !w.Logger.sample(w.Level, 0, msg) {
+		return len(p), nil
+	}
+	log := getLog()
+	*log = Log{
+		Now:    time.Now(),
+		Level:  w.Level,
+		Value:  msg,
+		Name:   w.Logger.name,
+		Fields: w.Logger.fields,
+	}
+	if file != "" {
+		log.frame = newCallerFrame(file, line)
+		log.Caller(w.Logger.root().FullPath)
+	} else {
+		log.File, log.Line = "???", -1
+	}
+	w.Logger.writeLog(log)
+	putLog(log)
+	return len(p), nil
+}
+
+// parseStdLog 按标准库 log 的 flags 去掉 prefix、日期时间，并解析 file:line
+func parseStdLog(s, prefix string, flags int) (file string, line int, msg string) {
+	s = strings.TrimSuffix(s, "\n")
+	if flags&stdlog.Lmsgprefix == 0 {
+		s = strings.TrimPrefix(s, prefix)
+	}
+	if flags&stdlog.Ldate != 0 && len(s) >= 11 {
+		s = s[11:]
+	}
+	if flags&(stdlog.Ltime|stdlog.Lmicroseconds) != 0 {
+		n := 9
+		if flags&stdlog.Lmicroseconds != 0 {
+			n += 7
+		}
+		if len(s) >= n {
+			s = s[n:]
+		}
+	}
+	if flags&(stdlog.Lshortfile|stdlog.Llongfile) != 0 {
+		if pos := strings.Index(s, ": "); pos > 0 {
+			loc := s[:pos]
+			if i := strings.LastIndex(loc, ":"); i > 0 {
+				if l, err := strconv.Atoi(loc[i+1:]); err == nil {
+					file, line, s = loc[:i], l, s[pos+2:]
+				}
+			}
+		}
+	}
+	if flags&stdlog.Lmsgprefix != 0 {
+		s = strings.TrimPrefix(s, prefix)
+	}
+	return file, line, s
+}
+
+// StdLogger 返回输出到 logger 的标准库 *log.Logger，用于 http.Server.ErrorLog 等接口
+func (logger *Logger) StdLogger(level int) *stdlog.Logger {
+	w := &StdWriter{Logger: logger, Level: level, Flags: stdlog.Llongfile}
+	return stdlog.New(w, "", w.Flags)
+}
+
+// RedirectStdLog 将标准库 log 的默认输出重定向到 logger，返回用于恢复原设置的函数
+func RedirectStdLog(logger *Logger, level int) func() {
+	prefix, flags, out := stdlog.Prefix(), stdlog.Flags(), stdlog.Writer()
+	w := &StdWriter{Logger: logger, Level: level, Prefix: prefix, Flags: flags&stdlog.Lmsgprefix | stdlog.Llongfile}
+	stdlog.SetFlags(w.Flags)
+	stdlog.SetOutput(w)
+	return func() {
+		stdlog.SetOutput(out)
+		stdlog.SetFlags(flags)
+		stdlog.SetPrefix(prefix)
+	}
+}
+
+func StdLogger(level int) *stdlog.Logger {
+	return DefaultLogger.StdLogger(level)
+}
@@ -0,0 +1,42 @@
+package log
+
+import (
+	"bytes"
+	stdlog "log"
+	"strings"
+	"testing"
+)
+
+func TestParseStdLog(t *testing.T) {
+	file, line, msg := parseStdLog("app: 2009/01/23 01:23:23.123123 C:/src/a.go:12: hello: world\n", "app: ", stdlog.LstdFlags|stdlog.Lmicroseconds|stdlog.Llongfile)
+	if file != "C:/src/a.go" || line != 12 || msg != "hello: world" {
+		t.Fatalf("got %q %d %q", file, line, msg)
+	}
+	_, _, msg = parseStdLog("01:23:23 a.go:1: [x] hi\n", "[x] ", stdlog.Ltime|stdlog.Lshortfile|stdlog.Lmsgprefix)
+	if msg != "hi" {
+		t.Fatalf("got %q", msg)
+	}
+}
+
+func TestStdLogBridge(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := NewLogger()
+	logger.SetOutput(buf)
+
+	logger.StdLogger(LEVEL_ERROR).Printf("from std")
+
+	restore := RedirectStdLog(logger.Named("std"), LEVEL_WARN)
+	stdlog.Print("redirected")
+	restore()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %q", buf.String())
+	}
+	if !strings.Contains(lines[0], " [Error] [stdlog_test.go:") || !strings.HasSuffix(lines[0], "] from std") {
+		t.Fatalf("unexpected line: %q", lines[0])
+	}
+	if !strings.Contains(lines[1], " [ Warn] [std] [stdlog_test.go:") || !strings.HasSuffix(lines[1], "] redirected") {
+		t.Fatalf("unexpected line: %q", lines[1])
+	}
+}